@@ -0,0 +1,14 @@
+// Command ecsvet runs the ecscheck analyzer under go vet:
+//
+//	go install github.com/liamg/ecs/analysis/cmd/ecsvet
+//	go vet -vettool=$(which ecsvet) ./...
+package main
+
+import (
+	"github.com/liamg/ecs/analysis/ecscheck"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(ecscheck.Analyzer)
+}
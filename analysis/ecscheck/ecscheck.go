@@ -0,0 +1,296 @@
+// Package ecscheck defines an Analyzer which reports common misuse of github.com/liamg/ecs:
+//
+//   - passing a value which is not a pointer to Entity.Add, World.AddComponentToEntity or World.AddResource, which
+//     panics at runtime;
+//   - calling Entity.Add on an entity which is already in a world, which does not add the entity to systems which now
+//     match it, where World.AddComponentToEntity should be used instead;
+//   - RequiredTypes methods returning values which are not pointers to interfaces, which panics in World.AddSystem.
+//
+// Whether an entity is already in a world is decided within a single function: the entity has been passed to
+// World.AddEntity, came from World.GetEntity, World.GetEntities, World.SystemEntities or World.Player, or is the
+// parameter of a system's Add method.
+//
+// Test files are not checked, as tests misuse the package on purpose to check that it panics.
+package ecscheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const ecsPath = "github.com/liamg/ecs"
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "ecscheck",
+	Doc:      "report common misuse of github.com/liamg/ecs",
+	URL:      "https://pkg.go.dev/github.com/liamg/ecs/analysis/ecscheck",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	in := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	in.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		decl := n.(*ast.FuncDecl)
+		if decl.Body == nil || strings.HasSuffix(pass.Fset.File(decl.Pos()).Name(), "_test.go") {
+			return
+		}
+		checkPointers(pass, decl)
+		checkEntityAdd(pass, decl)
+		checkRequiredTypes(pass, decl)
+	})
+	return nil, nil
+}
+
+// method returns the ecs method called by call, such as "Entity.Add", or "" if call is not a call to an ecs method
+// through a value, such as e.Add(c).
+func method(pass *analysis.Pass, call *ast.CallExpr) string {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	if selection, ok := pass.TypesInfo.Selections[sel]; !ok || selection.Kind() != types.MethodVal {
+		return ""
+	}
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != ecsPath {
+		return ""
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return ""
+	}
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return ""
+	}
+	return named.Obj().Name() + "." + fn.Name()
+}
+
+// checkPointers reports components and resources which are not pointers.
+func checkPointers(pass *analysis.Pass, decl *ast.FuncDecl) {
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		name := method(pass, call)
+		switch name {
+		case "Entity.Add", "World.AddComponentToEntity", "World.AddResource":
+		default:
+			return true
+		}
+		arg := call.Args[0]
+		t := pass.TypesInfo.TypeOf(arg)
+		if t == nil || isPointer(t) || types.IsInterface(t) {
+			return true
+		}
+		pass.Reportf(arg.Pos(), "%s passed to %s is not a pointer: components and resources must be pointers, such as &%s",
+			types.TypeString(t, types.RelativeTo(pass.Pkg)), name, types.ExprString(arg))
+		return true
+	})
+}
+
+// checkEntityAdd reports calls to Entity.Add on entities which are already in a world.
+func checkEntityAdd(pass *analysis.Pass, decl *ast.FuncDecl) {
+	// inWorld maps entity variables to the position from which they are known to be in a world
+	inWorld := make(map[types.Object]token.Pos)
+	mark := func(expr ast.Expr, from token.Pos) {
+		id, ok := ast.Unparen(expr).(*ast.Ident)
+		if !ok {
+			return
+		}
+		obj := pass.TypesInfo.ObjectOf(id)
+		if obj == nil {
+			return
+		}
+		if pos, ok := inWorld[obj]; !ok || from < pos {
+			inWorld[obj] = from
+		}
+	}
+
+	if isSystemAdd(pass, decl) {
+		for _, field := range decl.Type.Params.List {
+			for _, name := range field.Names {
+				mark(name, decl.Pos())
+			}
+		}
+	}
+
+	// fromWorld returns true if expr is a call which returns entities which are in a world
+	fromWorld := func(expr ast.Expr) bool {
+		call, ok := ast.Unparen(expr).(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		switch method(pass, call) {
+		case "World.GetEntity", "World.GetEntities", "World.SystemEntities", "World.Player":
+			return true
+		}
+		return false
+	}
+
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if method(pass, n) == "World.AddEntity" && len(n.Args) == 1 {
+				mark(n.Args[0], n.End())
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) == len(n.Rhs) {
+				for i, rhs := range n.Rhs {
+					if fromWorld(rhs) {
+						mark(n.Lhs[i], n.Pos())
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			if len(n.Names) == len(n.Values) {
+				for i, value := range n.Values {
+					if fromWorld(value) {
+						mark(n.Names[i], n.Pos())
+					}
+				}
+			}
+		case *ast.RangeStmt:
+			if n.Value != nil && fromWorld(n.X) {
+				mark(n.Value, n.Pos())
+			}
+		}
+		return true
+	})
+
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || method(pass, call) != "Entity.Add" {
+			return true
+		}
+		recv := ast.Unparen(ast.Unparen(call.Fun).(*ast.SelectorExpr).X)
+		inside := fromWorld(recv)
+		if id, ok := recv.(*ast.Ident); ok {
+			if pos, ok := inWorld[pass.TypesInfo.ObjectOf(id)]; ok && call.Pos() > pos {
+				inside = true
+			}
+		}
+		if inside {
+			pass.Reportf(call.Pos(), "Entity.Add called on an entity which is already in a world: "+
+				"use World.AddComponentToEntity so that systems are updated")
+		}
+		return true
+	})
+}
+
+// isSystemAdd returns true if decl is the Add method of a type which implements ecs.System.
+func isSystemAdd(pass *analysis.Pass, decl *ast.FuncDecl) bool {
+	if decl.Recv == nil || decl.Name.Name != "Add" {
+		return false
+	}
+	fn, ok := pass.TypesInfo.Defs[decl.Name].(*types.Func)
+	if !ok {
+		return false
+	}
+	system := lookupSystem(pass.Pkg)
+	if system == nil {
+		return false
+	}
+	recv := fn.Type().(*types.Signature).Recv().Type()
+	if types.Implements(recv, system) {
+		return true
+	}
+	if _, ok := recv.(*types.Pointer); !ok {
+		return types.Implements(types.NewPointer(recv), system)
+	}
+	return false
+}
+
+// lookupSystem returns the ecs.System interface, or nil if the package does not depend on ecs.
+func lookupSystem(pkg *types.Package) *types.Interface {
+	var find func(pkg *types.Package, seen map[*types.Package]bool) *types.Interface
+	find = func(pkg *types.Package, seen map[*types.Package]bool) *types.Interface {
+		if seen[pkg] {
+			return nil
+		}
+		seen[pkg] = true
+		if pkg.Path() == ecsPath {
+			if obj, ok := pkg.Scope().Lookup("System").(*types.TypeName); ok {
+				if iface, ok := obj.Type().Underlying().(*types.Interface); ok {
+					return iface
+				}
+			}
+			return nil
+		}
+		for _, imp := range pkg.Imports() {
+			if iface := find(imp, seen); iface != nil {
+				return iface
+			}
+		}
+		return nil
+	}
+	return find(pkg, make(map[*types.Package]bool))
+}
+
+// checkRequiredTypes reports RequiredTypes methods which return values which are not pointers to interfaces.
+func checkRequiredTypes(pass *analysis.Pass, decl *ast.FuncDecl) {
+	if decl.Recv == nil || decl.Name.Name != "RequiredTypes" {
+		return
+	}
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			if len(n.Results) != 1 {
+				return true
+			}
+			lit, ok := ast.Unparen(n.Results[0]).(*ast.CompositeLit)
+			if !ok {
+				return true
+			}
+			for _, elt := range lit.Elts {
+				checkRequiredType(pass, elt)
+			}
+		}
+		return true
+	})
+}
+
+func checkRequiredType(pass *analysis.Pass, elt ast.Expr) {
+	tv, ok := pass.TypesInfo.Types[elt]
+	if !ok {
+		return
+	}
+	if tv.IsNil() {
+		pass.Reportf(elt.Pos(), "RequiredTypes must return pointers to interfaces, such as (*HasPosition)(nil), not nil")
+		return
+	}
+	t := tv.Type
+	if types.IsInterface(t) {
+		return
+	}
+	ptr, ok := t.Underlying().(*types.Pointer)
+	if !ok {
+		pass.Reportf(elt.Pos(), "RequiredTypes must return pointers to interfaces, such as (*HasPosition)(nil): %s is not a pointer",
+			types.TypeString(t, types.RelativeTo(pass.Pkg)))
+		return
+	}
+	if !types.IsInterface(ptr.Elem()) {
+		pass.Reportf(elt.Pos(), "RequiredTypes must return pointers to interfaces, such as (*HasPosition)(nil): %s is not a pointer to an interface",
+			types.TypeString(t, types.RelativeTo(pass.Pkg)))
+	}
+}
+
+func isPointer(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Pointer)
+	return ok
+}
@@ -0,0 +1,77 @@
+package ecscheck
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
+
+// TestStubMatchesPackage checks that everything declared by the testdata stub of the package matches the real
+// declaration, so that the analyzer is tested against the API users see.
+func TestStubMatchesPackage(t *testing.T) {
+	stub := declarations(t, filepath.Join(analysistest.TestData(), "src", "github.com", "liamg", "ecs", "*.go"))
+	real := declarations(t, filepath.Join("..", "..", "*.go"))
+	for name, signature := range stub {
+		if real[name] != signature {
+			t.Errorf("stub declares %s as %s, but the package declares it as %s", name, signature, real[name])
+		}
+	}
+}
+
+// declarations returns the signatures of the functions, methods and interface methods declared by the non-test files
+// matching pattern, keyed by name.
+func declarations(t *testing.T, pattern string) map[string]string {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decls := make(map[string]string)
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				name := decl.Name.Name
+				if decl.Recv != nil {
+					name = "(" + types.ExprString(decl.Recv.List[0].Type) + ")." + name
+				}
+				decls[name] = types.ExprString(decl.Type)
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					spec, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					iface, ok := spec.Type.(*ast.InterfaceType)
+					if !ok {
+						decls[spec.Name.Name] = "type"
+						continue
+					}
+					for _, method := range iface.Methods.List {
+						for _, name := range method.Names {
+							decls[spec.Name.Name+"."+name.Name] = types.ExprString(method.Type)
+						}
+					}
+				}
+			}
+		}
+	}
+	return decls
+}
@@ -0,0 +1,88 @@
+package a
+
+import (
+	"github.com/google/uuid"
+	"github.com/liamg/ecs"
+)
+
+type Position struct {
+	X, Y int
+}
+
+type HasPosition interface {
+	Position() *Position
+}
+
+func (p *Position) Position() *Position { return p }
+
+func components(w *ecs.World, e *ecs.Entity) {
+	position := Position{}
+	var component ecs.Component = &position
+	e.Add(position)                     // want `Position passed to Entity.Add is not a pointer: components and resources must be pointers, such as &position`
+	e.Add(Position{X: 1})               // want `Position passed to Entity.Add is not a pointer`
+	w.AddComponentToEntity(position, e) // want `Position passed to World.AddComponentToEntity is not a pointer`
+	w.AddResource(Position{})           // want `Position passed to World.AddResource is not a pointer`
+	e.Add(&position)
+	e.Add(component)
+	w.AddComponentToEntity(&Position{}, e)
+	(*ecs.Entity).Add(e, &position)
+}
+
+func addedToWorld(w *ecs.World) {
+	e := ecs.NewEntity()
+	e.Add(&Position{})
+	w.AddEntity(e)
+	e.Add(&Position{}) // want `Entity.Add called on an entity which is already in a world: use World.AddComponentToEntity so that systems are updated`
+	w.AddComponentToEntity(&Position{}, e)
+}
+
+func updated(w *ecs.World) error {
+	e := ecs.NewEntity()
+	w.AddEntity(e)
+	w.Update()
+	e.Add(&Position{}) // want `Entity.Add called on an entity which is already in a world`
+	return w.TryUpdate()
+}
+
+func fromWorld(w *ecs.World, id uuid.UUID) {
+	for _, e := range w.GetEntities() {
+		e.Add(&Position{}) // want `Entity.Add called on an entity which is already in a world`
+	}
+	found := w.GetEntity(id)
+	found.Add(&Position{})      // want `Entity.Add called on an entity which is already in a world`
+	w.Player().Add(&Position{}) // want `Entity.Add called on an entity which is already in a world`
+	ecs.NewEntity().Add(&Position{})
+}
+
+type MovementSystem struct {
+	entities []*ecs.Entity
+}
+
+func (s *MovementSystem) Add(e *ecs.Entity) {
+	e.Add(&Position{}) // want `Entity.Add called on an entity which is already in a world`
+	s.entities = append(s.entities, e)
+}
+
+func (s *MovementSystem) Update(w *ecs.World, player *ecs.Entity) {
+	for _, e := range w.SystemEntities(s) {
+		w.AddComponentToEntity(&Position{}, e)
+	}
+}
+
+func (s *MovementSystem) Remove(e *ecs.Entity) {}
+
+func (s *MovementSystem) RequiredTypes() []interface{} {
+	return []interface{}{
+		(*HasPosition)(nil),
+		nil,         // want `RequiredTypes must return pointers to interfaces, such as \(\*HasPosition\)\(nil\), not nil`
+		Position{},  // want `RequiredTypes must return pointers to interfaces, such as \(\*HasPosition\)\(nil\): Position is not a pointer`
+		&Position{}, // want `RequiredTypes must return pointers to interfaces, such as \(\*HasPosition\)\(nil\): \*Position is not a pointer to an interface`
+	}
+}
+
+// Builder is not a system, so the entities it adds components to may not be in a world.
+type Builder struct{}
+
+func (b *Builder) Add(e *ecs.Entity) {
+	e.Add(&Position{})
+}
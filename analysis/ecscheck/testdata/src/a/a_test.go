@@ -0,0 +1,12 @@
+package a
+
+import (
+	"testing"
+
+	"github.com/liamg/ecs"
+)
+
+// Tests misuse the package on purpose, to check that it panics, so they are not checked.
+func TestAddPanics(t *testing.T) {
+	ecs.NewEntity().Add(Position{})
+}
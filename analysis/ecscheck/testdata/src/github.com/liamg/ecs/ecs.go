@@ -0,0 +1,38 @@
+// Package ecs is a stub of github.com/liamg/ecs holding just what the analyzer needs.
+package ecs
+
+import "github.com/google/uuid"
+
+type Component interface{}
+
+type System interface {
+	Add(entity *Entity)
+	Update(world *World, player *Entity)
+	Remove(entity *Entity)
+	RequiredTypes() []interface{}
+}
+
+type Entity struct{}
+
+func NewEntity() *Entity                               { return &Entity{} }
+func (e *Entity) Add(component Component)              {}
+func (e *Entity) Remove(component Component)           {}
+func (e *Entity) Component(face interface{}) Component { return nil }
+
+type World struct{}
+
+func NewWorld(turn int64) *World                               { return &World{} }
+func (w *World) AddEntity(e *Entity)                           {}
+func (w *World) AddSystem(system System, repeatable bool)      {}
+func (w *World) Run()                                          {}
+func (w *World) TryRun() error                                 { return nil }
+func (w *World) Update()                                       {}
+func (w *World) TryUpdate() error                              { return nil }
+func (w *World) UpdateRepeatable()                             {}
+func (w *World) TryUpdateRepeatable() error                    { return nil }
+func (w *World) AddComponentToEntity(c interface{}, e *Entity) {}
+func (w *World) AddResource(resource interface{})              {}
+func (w *World) GetEntity(id uuid.UUID) *Entity                { return nil }
+func (w *World) GetEntities() []*Entity                        { return nil }
+func (w *World) SystemEntities(system System) []*Entity        { return nil }
+func (w *World) Player() *Entity                               { return nil }
@@ -0,0 +1,11 @@
+module github.com/liamg/ecs/analysis
+
+// x/tools v0.38.0 is the oldest release which reports diagnostics through go vet -vettool from Go 1.26 onwards.
+go 1.24.0
+
+require golang.org/x/tools v0.38.0
+
+require (
+	golang.org/x/mod v0.29.0 // indirect
+	golang.org/x/sync v0.17.0 // indirect
+)
@@ -0,0 +1,321 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+// The binary format is laid out as follows. Integers are varints unless stated otherwise, and strings are a length
+// followed by bytes.
+//
+//	magic "ECSB", version (1 byte), kind (1 byte, 'W' for a world or 'E' for an entity)
+//	string table: count, strings...
+//	world:      turn, controller count, (name, entity UUID)..., resources, entity count, entities...
+//	entity:     UUID (16 bytes), flags (1 for a disabled entity), components
+//	components: count, (type name string table index << 1 | codec flag, data length, data)...
+//
+// Component data is JSON, unless the codec flag is set, in which case it is the raw output of the component's codec.
+// Version 1 saves have no entity flags, and component type indexes have no codec flag, as all of their data is JSON.
+var binaryMagic = []byte("ECSB")
+
+const (
+	binaryVersion     byte = 2
+	binaryKindWorld   byte = 'W'
+	binaryKindEntity  byte = 'E'
+	maxBinaryDataSize      = 1 << 30
+	// binaryFlagDisabled marks a disabled entity.
+	binaryFlagDisabled = 1
+)
+
+func isBinary(data []byte) bool {
+	return bytes.HasPrefix(data, binaryMagic)
+}
+
+type binaryWriter struct {
+	out     io.Writer
+	err     error
+	strings map[string]uint64
+	buf     [binary.MaxVarintLen64]byte
+}
+
+func newBinaryWriter(out io.Writer, kind byte) *binaryWriter {
+	w := &binaryWriter{
+		out:     out,
+		strings: make(map[string]uint64),
+	}
+	w.write(binaryMagic)
+	w.write([]byte{binaryVersion, kind})
+	return w
+}
+
+func (w *binaryWriter) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = w.out.Write(data)
+}
+
+func (w *binaryWriter) uvarint(v uint64) {
+	n := binary.PutUvarint(w.buf[:], v)
+	w.write(w.buf[:n])
+}
+
+func (w *binaryWriter) varint(v int64) {
+	n := binary.PutVarint(w.buf[:], v)
+	w.write(w.buf[:n])
+}
+
+func (w *binaryWriter) bytes(data []byte) {
+	w.uvarint(uint64(len(data)))
+	w.write(data)
+}
+
+func (w *binaryWriter) uuid(id uuid.UUID) {
+	w.write(id[:])
+}
+
+func (w *binaryWriter) stringTable(names []string) {
+	w.uvarint(uint64(len(names)))
+	for i, name := range names {
+		w.strings[name] = uint64(i)
+		w.bytes([]byte(name))
+	}
+}
+
+func (w *binaryWriter) components(components []rawComponent) {
+	w.uvarint(uint64(len(components)))
+	for _, c := range components {
+		index := w.strings[c.name] << 1
+		if c.codec {
+			index |= 1
+		}
+		w.uvarint(index)
+		w.bytes(c.data)
+	}
+}
+
+func (w *binaryWriter) entity(e rawEntity) {
+	w.uuid(e.id)
+	var flags uint64
+	if e.disabled {
+		flags |= binaryFlagDisabled
+	}
+	w.uvarint(flags)
+	w.components(e.components)
+}
+
+func writeBinaryWorld(out io.Writer, src worldSource) error {
+	w := newBinaryWriter(out, binaryKindWorld)
+	w.stringTable(src.componentNames())
+	w.varint(src.turn())
+	controllers := src.controllers()
+	w.uvarint(uint64(len(controllers)))
+	for _, c := range controllers {
+		w.bytes([]byte(c.Name))
+		w.uuid(c.Entity)
+	}
+	resources, err := src.resources()
+	if err != nil {
+		return err
+	}
+	w.components(resources)
+	w.uvarint(uint64(src.entityCount()))
+	for i := 0; i < src.entityCount() && w.err == nil; i++ {
+		e, err := src.entity(i)
+		if err != nil {
+			return err
+		}
+		w.entity(e)
+	}
+	return w.err
+}
+
+func writeBinaryEntity(out io.Writer, e rawEntity) error {
+	var names uniqueNames
+	for _, c := range e.components {
+		names.add(c.name)
+	}
+	w := newBinaryWriter(out, binaryKindEntity)
+	w.stringTable(names.names)
+	w.entity(e)
+	return w.err
+}
+
+var errBinaryTruncated = errors.New("binary save is truncated")
+
+type binaryReader struct {
+	in      *bytes.Reader
+	version byte
+	strings []string
+}
+
+func newBinaryReader(data []byte, kind byte) (*binaryReader, error) {
+	if len(data) < len(binaryMagic)+2 || !isBinary(data) {
+		return nil, fmt.Errorf("not a binary save")
+	}
+	version := data[len(binaryMagic)]
+	if version < 1 || version > binaryVersion {
+		return nil, fmt.Errorf("unsupported binary save version %d", version)
+	}
+	if actual := data[len(binaryMagic)+1]; actual != kind {
+		return nil, fmt.Errorf("binary save contains '%c', expected '%c'", actual, kind)
+	}
+	r := &binaryReader{
+		in:      bytes.NewReader(data[len(binaryMagic)+2:]),
+		version: version,
+	}
+	count, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < count; i++ {
+		name, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		r.strings = append(r.strings, string(name))
+	}
+	return r, nil
+}
+
+func (r *binaryReader) uvarint() (uint64, error) {
+	v, err := binary.ReadUvarint(r.in)
+	if err == io.EOF {
+		return 0, errBinaryTruncated
+	}
+	return v, err
+}
+
+func (r *binaryReader) varint() (int64, error) {
+	v, err := binary.ReadVarint(r.in)
+	if err == io.EOF {
+		return 0, errBinaryTruncated
+	}
+	return v, err
+}
+
+func (r *binaryReader) bytes() ([]byte, error) {
+	size, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if size > maxBinaryDataSize || size > uint64(r.in.Len()) {
+		return nil, errBinaryTruncated
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.in, data); err != nil {
+		return nil, errBinaryTruncated
+	}
+	return data, nil
+}
+
+func (r *binaryReader) uuid() (uuid.UUID, error) {
+	var id uuid.UUID
+	if _, err := io.ReadFull(r.in, id[:]); err != nil {
+		return id, errBinaryTruncated
+	}
+	return id, nil
+}
+
+func (r *binaryReader) components() ([]rawComponent, error) {
+	count, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	var components []rawComponent
+	for i := uint64(0); i < count; i++ {
+		index, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		var codec bool
+		if r.version >= 2 {
+			index, codec = index>>1, index&1 == 1
+		}
+		if index >= uint64(len(r.strings)) {
+			return nil, fmt.Errorf("component type index %d is out of range", index)
+		}
+		data, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, rawComponent{
+			name:  r.strings[index],
+			data:  data,
+			codec: codec,
+		})
+	}
+	return components, nil
+}
+
+func (r *binaryReader) entity() (rawEntity, error) {
+	id, err := r.uuid()
+	if err != nil {
+		return rawEntity{}, err
+	}
+	var flags uint64
+	if r.version >= 2 {
+		if flags, err = r.uvarint(); err != nil {
+			return rawEntity{}, err
+		}
+	}
+	components, err := r.components()
+	return rawEntity{
+		id:         id,
+		disabled:   flags&binaryFlagDisabled != 0,
+		components: components,
+	}, err
+}
+
+func readBinaryWorld(data []byte) (*frozenWorld, error) {
+	r, err := newBinaryReader(data, binaryKindWorld)
+	if err != nil {
+		return nil, err
+	}
+	saved := &frozenWorld{}
+	if saved.frozenTurn, err = r.varint(); err != nil {
+		return nil, err
+	}
+	count, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < count; i++ {
+		name, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		id, err := r.uuid()
+		if err != nil {
+			return nil, err
+		}
+		saved.frozenControllers = append(saved.frozenControllers, savedController{Name: string(name), Entity: id})
+	}
+	if saved.frozenResources, err = r.components(); err != nil {
+		return nil, err
+	}
+	if count, err = r.uvarint(); err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < count; i++ {
+		e, err := r.entity()
+		if err != nil {
+			return nil, err
+		}
+		saved.entities = append(saved.entities, e)
+	}
+	return saved, nil
+}
+
+func readBinaryEntity(data []byte) (rawEntity, error) {
+	r, err := newBinaryReader(data, binaryKindEntity)
+	if err != nil {
+		return rawEntity{}, err
+	}
+	return r.entity()
+}
@@ -0,0 +1,196 @@
+package ecs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Checksum is a hash of the state of a world. See World.Checksum.
+type Checksum [sha256.Size]byte
+
+func (c Checksum) String() string {
+	return hex.EncodeToString(c[:])
+}
+
+// Checksum returns a hash of the turn, entities, components and resources of the world, including whether each entity
+// is disabled. Components are hashed using the same type name and JSON data that are used to save them. The result
+// does not depend on the order of entities, components or resources, so two worlds with the same state always have
+// the same checksum.
+func (w *World) Checksum() (Checksum, error) {
+	var sum Checksum
+	state, err := canonicalise(w)
+	if err != nil {
+		return sum, err
+	}
+	h := sha256.New()
+	writeUint64(h, uint64(state.turn))
+	writeComponents(h, state.resources)
+	writeUint64(h, uint64(len(state.entities)))
+	for _, e := range state.entities {
+		_, _ = h.Write(e.id[:])
+		if e.disabled {
+			_, _ = h.Write([]byte("disabled"))
+		}
+		writeComponents(h, e.components)
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// Divergence describes the first difference found between two worlds by CompareWorlds.
+type Divergence struct {
+	// Entity is the entity which differs, or the zero UUID if the difference is in the turn or the resources.
+	Entity uuid.UUID
+	// Component is the type name of the component which differs, if any.
+	Component string
+	Reason    string
+}
+
+func (d *Divergence) String() string {
+	switch {
+	case d.Entity == uuid.Nil && d.Component == "":
+		return d.Reason
+	case d.Entity == uuid.Nil:
+		return fmt.Sprintf("resource %s: %s", d.Component, d.Reason)
+	case d.Component == "":
+		return fmt.Sprintf("entity %s: %s", d.Entity, d.Reason)
+	}
+	return fmt.Sprintf("entity %s, component %s: %s", d.Entity, d.Component, d.Reason)
+}
+
+// CompareWorlds compares the state of two worlds in the same way as World.Checksum, and returns the first difference
+// between them, ordered by entity UUID. It returns nil if the worlds are identical.
+func CompareWorlds(a, b *World) (*Divergence, error) {
+	stateA, err := canonicalise(a)
+	if err != nil {
+		return nil, err
+	}
+	stateB, err := canonicalise(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if stateA.turn != stateB.turn {
+		return &Divergence{Reason: fmt.Sprintf("turn differs: %d != %d", stateA.turn, stateB.turn)}, nil
+	}
+
+	if d := compareComponents(stateA.resources, stateB.resources); d != nil {
+		return d, nil
+	}
+
+	var i, j int
+	for i < len(stateA.entities) || j < len(stateB.entities) {
+		switch {
+		case j == len(stateB.entities) || (i < len(stateA.entities) && bytes.Compare(stateA.entities[i].id[:], stateB.entities[j].id[:]) < 0):
+			return &Divergence{Entity: stateA.entities[i].id, Reason: "entity only exists in first world"}, nil
+		case i == len(stateA.entities) || bytes.Compare(stateA.entities[i].id[:], stateB.entities[j].id[:]) > 0:
+			return &Divergence{Entity: stateB.entities[j].id, Reason: "entity only exists in second world"}, nil
+		}
+		if d := compareComponents(stateA.entities[i].components, stateB.entities[j].components); d != nil {
+			d.Entity = stateA.entities[i].id
+			return d, nil
+		}
+		if stateA.entities[i].disabled != stateB.entities[j].disabled {
+			return &Divergence{Entity: stateA.entities[i].id, Reason: "entity is only disabled in one world"}, nil
+		}
+		i++
+		j++
+	}
+
+	return nil, nil
+}
+
+func compareComponents(a, b []savedComponent) *Divergence {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		switch {
+		case i == len(b):
+			return &Divergence{Component: a[i].Type, Reason: "component only exists in first world"}
+		case i == len(a):
+			return &Divergence{Component: b[i].Type, Reason: "component only exists in second world"}
+		case a[i].Type != b[i].Type:
+			return &Divergence{Component: a[i].Type, Reason: fmt.Sprintf("component type differs: %s != %s", a[i].Type, b[i].Type)}
+		case !bytes.Equal(a[i].Data, b[i].Data):
+			return &Divergence{Component: a[i].Type, Reason: fmt.Sprintf("data differs: %s != %s", a[i].Data, b[i].Data)}
+		}
+	}
+	return nil
+}
+
+type canonicalState struct {
+	turn      int64
+	resources []savedComponent
+	entities  []canonicalEntity
+}
+
+type canonicalEntity struct {
+	id         uuid.UUID
+	disabled   bool
+	components []savedComponent
+}
+
+// canonicalise encodes the state of a world with entities sorted by UUID, and components sorted by type and data.
+func canonicalise(w *World) (*canonicalState, error) {
+	state := &canonicalState{
+		turn: w.turn,
+	}
+	var err error
+	if state.resources, err = canonicalComponents(w.resources); err != nil {
+		return nil, err
+	}
+	for _, e := range w.entities {
+		components, err := canonicalComponents(e.Store)
+		if err != nil {
+			return nil, err
+		}
+		state.entities = append(state.entities, canonicalEntity{
+			id:         e.ID(),
+			disabled:   e.Disabled,
+			components: components,
+		})
+	}
+	sort.Slice(state.entities, func(i, j int) bool {
+		return bytes.Compare(state.entities[i].id[:], state.entities[j].id[:]) < 0
+	})
+	return state, nil
+}
+
+func canonicalComponents(store *ComponentStore) ([]savedComponent, error) {
+	var components []savedComponent
+	for _, c := range store.components {
+		saved, err := c.saved()
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, saved)
+	}
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Type != components[j].Type {
+			return components[i].Type < components[j].Type
+		}
+		return bytes.Compare(components[i].Data, components[j].Data) < 0
+	})
+	return components, nil
+}
+
+func writeComponents(h hash.Hash, components []savedComponent) {
+	writeUint64(h, uint64(len(components)))
+	for _, c := range components {
+		writeUint64(h, uint64(len(c.Type)))
+		_, _ = h.Write([]byte(c.Type))
+		writeUint64(h, uint64(len(c.Data)))
+		_, _ = h.Write(c.Data)
+	}
+}
+
+func writeUint64(h hash.Hash, v uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	_, _ = h.Write(buf[:])
+}
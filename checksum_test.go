@@ -0,0 +1,107 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildChecksumWorld(reverse bool) *World {
+	world := NewWorld(5)
+	world.SetSeed(1)
+	ids := NewSequentialIDGenerator(9)
+
+	var entities []*Entity
+	for i := 0; i < 3; i++ {
+		e := &Entity{UUID: ids.NewID(), Store: &ComponentStore{}}
+		if reverse {
+			e.Add(&TestComponent{X: i * 10})
+			e.Add(&TestComponent{X: i})
+		} else {
+			e.Add(&TestComponent{X: i})
+			e.Add(&TestComponent{X: i * 10})
+		}
+		entities = append(entities, e)
+	}
+	if reverse {
+		for i := len(entities) - 1; i >= 0; i-- {
+			world.AddEntity(entities[i])
+		}
+	} else {
+		for _, e := range entities {
+			world.AddEntity(e)
+		}
+	}
+	return world
+}
+
+func TestChecksumIsIndependentOfOrder(t *testing.T) {
+	a, err := buildChecksumWorld(false).Checksum()
+	require.NoError(t, err)
+	b, err := buildChecksumWorld(true).Checksum()
+	require.NoError(t, err)
+
+	assert.Equal(t, a, b)
+	assert.Len(t, a.String(), 64)
+}
+
+func TestChecksumChangesWithState(t *testing.T) {
+	world := buildChecksumWorld(false)
+	before, err := world.Checksum()
+	require.NoError(t, err)
+
+	var testable *Testable
+	world.GetEntities()[1].Component(testable).(Testable).TestComponent().X = 1000
+	afterComponent, err := world.Checksum()
+	require.NoError(t, err)
+	assert.NotEqual(t, before, afterComponent)
+
+	world.UseTurn()
+	afterTurn, err := world.Checksum()
+	require.NoError(t, err)
+	assert.NotEqual(t, afterComponent, afterTurn)
+
+	world.RNG().Uint64()
+	afterResource, err := world.Checksum()
+	require.NoError(t, err)
+	assert.NotEqual(t, afterTurn, afterResource)
+}
+
+func TestCompareWorldsFindsNoDifferenceInIdenticalWorlds(t *testing.T) {
+	divergence, err := CompareWorlds(buildChecksumWorld(false), buildChecksumWorld(true))
+	require.NoError(t, err)
+	assert.Nil(t, divergence)
+}
+
+func TestCompareWorldsReportsFirstDifferingComponent(t *testing.T) {
+	a := buildChecksumWorld(false)
+	b := buildChecksumWorld(false)
+
+	target := b.GetEntities()[2]
+	var testable *Testable
+	target.Component(testable).(Testable).TestComponent().X = 1000
+
+	divergence, err := CompareWorlds(a, b)
+	require.NoError(t, err)
+	require.NotNil(t, divergence)
+
+	assert.Equal(t, target.ID(), divergence.Entity)
+	assert.Equal(t, "TestComponent", divergence.Component)
+	assert.Contains(t, divergence.String(), target.ID().String())
+}
+
+func TestCompareWorldsReportsMissingEntity(t *testing.T) {
+	a := buildChecksumWorld(false)
+	b := buildChecksumWorld(false)
+
+	removed := b.GetEntities()[0]
+	b.RemoveEntity(removed)
+
+	divergence, err := CompareWorlds(a, b)
+	require.NoError(t, err)
+	require.NotNil(t, divergence)
+
+	assert.Equal(t, removed.ID(), divergence.Entity)
+	assert.Equal(t, "entity only exists in first world", divergence.Reason)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/liamg/ecs"
+)
+
+func listCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	typeName := flags.String("type", "", "only list entities with a component of this type")
+	positional, err := parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	doc, err := readDocument(positional[0])
+	if err != nil {
+		return err
+	}
+	for _, e := range doc.Entities {
+		if *typeName != "" && !e.Has(*typeName) {
+			continue
+		}
+		var state string
+		if e.Disabled {
+			state = " (disabled)"
+		}
+		fmt.Fprintf(stdout, "%s%s  %s\n", e.UUID, state, strings.Join(componentTypes(e), ", "))
+	}
+	return nil
+}
+
+func showCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("show", flag.ContinueOnError)
+	typeName := flags.String("type", "", "only show components of this type")
+	positional, err := parse(flags, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	doc, err := readDocument(positional[0])
+	if err != nil {
+		return err
+	}
+	e, err := findEntity(doc, positional[1])
+	if err != nil {
+		return err
+	}
+	if e.Disabled {
+		fmt.Fprintf(stdout, "entity %s (disabled)\n", e.UUID)
+	} else {
+		fmt.Fprintf(stdout, "entity %s\n", e.UUID)
+	}
+	for _, c := range e.Components {
+		if *typeName != "" && c.Type != *typeName {
+			continue
+		}
+		if c.Codec {
+			fmt.Fprintf(stdout, "  %s (codec, %d bytes)\n    %s\n", c.Type, len(c.Data), base64.StdEncoding.EncodeToString(c.Data))
+			continue
+		}
+		indented := bytes.NewBuffer(nil)
+		if err := json.Indent(indented, c.Data, "    ", "  "); err != nil {
+			return fmt.Errorf("%s has invalid data: %w", c.Type, err)
+		}
+		fmt.Fprintf(stdout, "  %s\n    %s\n", c.Type, indented)
+	}
+	return nil
+}
+
+func countCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("count", flag.ContinueOnError)
+	positional, err := parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	doc, err := readDocument(positional[0])
+	if err != nil {
+		return err
+	}
+	components := make(map[string]int)
+	entities := make(map[string]int)
+	for _, e := range doc.Entities {
+		seen := make(map[string]bool)
+		for _, c := range e.Components {
+			components[c.Type]++
+			if !seen[c.Type] {
+				seen[c.Type] = true
+				entities[c.Type]++
+			}
+		}
+	}
+	table := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "TYPE\tCOMPONENTS\tENTITIES")
+	for _, t := range sortedKeys(components) {
+		fmt.Fprintf(table, "%s\t%d\t%d\n", t, components[t], entities[t])
+	}
+	fmt.Fprintf(table, "total\t\t%d\n", len(doc.Entities))
+	return table.Flush()
+}
+
+func setCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("set", flag.ContinueOnError)
+	index := flags.Int("index", 0, "index of the component amongst those of the same type")
+	output := flags.String("o", "", "write the edited save to this file instead")
+	positional, err := parse(flags, args, 5, 5)
+	if err != nil {
+		return err
+	}
+	path, typeName, pointer, value := positional[0], positional[2], positional[3], positional[4]
+	doc, err := readDocument(path)
+	if err != nil {
+		return err
+	}
+	e, err := findEntity(doc, positional[1])
+	if err != nil {
+		return err
+	}
+	c := e.Component(typeName, *index)
+	if c == nil {
+		return fmt.Errorf("entity %s has no component %s[%d]", e.UUID, typeName, *index)
+	}
+	// values which are not valid JSON are taken to be strings
+	data := json.RawMessage(value)
+	if !json.Valid(data) {
+		if data, err = json.Marshal(value); err != nil {
+			return err
+		}
+	}
+	if err := c.Set(pointer, data); err != nil {
+		return err
+	}
+	if *output != "" {
+		path = *output
+	}
+	return writeDocument(path, doc, doc.Options)
+}
+
+func deleteCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("delete", flag.ContinueOnError)
+	output := flags.String("o", "", "write the edited save to this file instead")
+	positional, err := parse(flags, args, 2, -1)
+	if err != nil {
+		return err
+	}
+	path := positional[0]
+	doc, err := readDocument(path)
+	if err != nil {
+		return err
+	}
+	if doc.SingleEntity {
+		return fmt.Errorf("cannot delete the only entity of an entity save")
+	}
+	for _, s := range positional[1:] {
+		id, err := parseUUID(s)
+		if err != nil {
+			return err
+		}
+		if !doc.RemoveEntity(id) {
+			return fmt.Errorf("entity %s not found", id)
+		}
+	}
+	if *output != "" {
+		path = *output
+	}
+	return writeDocument(path, doc, doc.Options)
+}
+
+func convertCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	formatName := flags.String("format", "", "json, yaml or binary (default: from the extension of OUT, or json)")
+	compress := flags.Bool("compress", false, "gzip the converted save")
+	positional, err := parse(flags, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	doc, err := readDocument(positional[0])
+	if err != nil {
+		return err
+	}
+	if *formatName == "" {
+		*formatName = strings.TrimPrefix(filepath.Ext(positional[1]), ".")
+		if _, err := parseFormat(*formatName); err != nil {
+			*formatName = "json"
+		}
+	}
+	format, err := parseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	return writeDocument(positional[1], doc, ecs.SaveOptions{Format: format, Compress: *compress})
+}
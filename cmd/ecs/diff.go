@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/liamg/ecs"
+)
+
+// diffCommand compares two saves. Like diff(1), it exits with status 1 if the saves differ and 2 if they could not
+// be compared.
+func diffCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the difference as a patch which can be applied with World.ApplyPatch")
+	positional, err := parse(flags, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	a, err := readDocument(positional[0])
+	if err != nil {
+		return &exitError{code: 2, err: err}
+	}
+	b, err := readDocument(positional[1])
+	if err != nil {
+		return &exitError{code: 2, err: err}
+	}
+	patch, err := ecs.DiffDocuments(a, b)
+	if err != nil {
+		return &exitError{code: 2, err: err}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(patch); err != nil {
+			return &exitError{code: 2, err: err}
+		}
+	} else {
+		printPatch(stdout, positional[0], positional[1], a.Turn, patch)
+	}
+
+	if a.Turn != b.Turn || !patch.Empty() {
+		return &exitError{code: 1}
+	}
+	return nil
+}
+
+// printPatch prints a patch in the style of a unified diff: removals are marked with -, additions with + and changes
+// with ~.
+func printPatch(out io.Writer, nameA, nameB string, turnA int64, patch *ecs.Patch) {
+	if turnA == patch.Turn && patch.Empty() {
+		return
+	}
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", nameA, nameB)
+	if turnA != patch.Turn {
+		fmt.Fprintf(out, "~ turn: %d -> %d\n", turnA, patch.Turn)
+	}
+	if !patch.Resources.Empty() {
+		fmt.Fprintln(out, "~ resources")
+		printComponents(out, patch.Resources)
+	}
+	for _, id := range patch.Removed {
+		fmt.Fprintf(out, "- entity %s\n", id)
+	}
+	for _, e := range patch.Added {
+		fmt.Fprintf(out, "+ entity %s\n", e.UUID)
+		printDisabled(out, e)
+		printComponents(out, e.ComponentsPatch)
+	}
+	for _, e := range patch.Changed {
+		fmt.Fprintf(out, "~ entity %s\n", e.UUID)
+		printDisabled(out, e)
+		printComponents(out, e.ComponentsPatch)
+	}
+	for _, c := range patch.Controllers {
+		if c.Entity == nil {
+			fmt.Fprintf(out, "- controller %s\n", c.Name)
+		} else {
+			fmt.Fprintf(out, "~ controller %s: %s\n", c.Name, c.Entity)
+		}
+	}
+}
+
+func printDisabled(out io.Writer, e ecs.EntityPatch) {
+	if e.Disabled != nil {
+		fmt.Fprintf(out, "    ~ disabled: %t\n", *e.Disabled)
+	}
+}
+
+func printComponents(out io.Writer, patch ecs.ComponentsPatch) {
+	for _, c := range patch.Removed {
+		fmt.Fprintf(out, "    - %s[%d]\n", c.Type, c.Index)
+	}
+	for _, c := range patch.Added {
+		fmt.Fprintf(out, "    + %s[%d] %s\n", c.Type, c.Index, c.Data)
+	}
+	for _, c := range patch.Changed {
+		fmt.Fprintf(out, "    ~ %s[%d]\n", c.Type, c.Index)
+		for _, field := range c.Fields {
+			path := field.Path
+			if path == "" {
+				path = "/"
+			}
+			switch {
+			case field.From == nil:
+				fmt.Fprintf(out, "        + %s: %s\n", path, field.To)
+			case field.To == nil:
+				fmt.Fprintf(out, "        - %s: %s\n", path, field.From)
+			default:
+				fmt.Fprintf(out, "        ~ %s: %s -> %s\n", path, field.From, field.To)
+			}
+		}
+	}
+}
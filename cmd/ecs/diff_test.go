@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/liamg/ecs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffOfIdenticalSavesIsSilent(t *testing.T) {
+	dir := tempDir(t)
+	path := writeWorld(t, dir)
+	converted := filepath.Join(dir, "world.bin")
+	_, _, code := runCommand(t, "convert", path, converted)
+	require.Equal(t, 0, code)
+
+	out, stderr, code := runCommand(t, "diff", path, converted)
+	assert.Equal(t, 0, code, stderr)
+	assert.Empty(t, out)
+}
+
+func TestDiffOfDifferentlyEncodedStateIsSilent(t *testing.T) {
+	dir := tempDir(t)
+	path := writeWorld(t, dir)
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	original := `{"x":1,"y":2}`
+	require.Contains(t, string(data), original)
+	// the same component with its members reordered and spaced out
+	data = []byte(strings.Replace(string(data), original, `{ "y": 2, "x": 1 }`, 1))
+	reformatted := filepath.Join(dir, "reformatted.json")
+	require.NoError(t, ioutil.WriteFile(reformatted, data, 0o644))
+
+	out, stderr, code := runCommand(t, "diff", path, reformatted)
+	assert.Equal(t, 0, code, stderr)
+	assert.Empty(t, out)
+}
+
+func TestDiffPrintsEntityAndFieldChanges(t *testing.T) {
+	dir := tempDir(t)
+	path := writeWorld(t, dir)
+	edited := filepath.Join(dir, "edited.json")
+	_, _, code := runCommand(t, "set", "-o", edited, path, playerID.String(), "Position", "/x", "7")
+	require.Equal(t, 0, code)
+	_, _, code = runCommand(t, "delete", edited, rockID.String())
+	require.Equal(t, 0, code)
+
+	out, _, code := runCommand(t, "diff", path, edited)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, `--- `+path+`
++++ `+edited+`
+- entity `+rockID.String()+`
+~ entity `+playerID.String()+`
+    ~ Position[0]
+        ~ /x: 1 -> 7
+`, out)
+}
+
+func TestDiffJSONOutputIsAPatch(t *testing.T) {
+	dir := tempDir(t)
+	path := writeWorld(t, dir)
+	edited := filepath.Join(dir, "edited.json")
+	_, _, code := runCommand(t, "set", "-o", edited, path, rockID.String(), "Position", "/y", "9")
+	require.Equal(t, 0, code)
+
+	out, _, code := runCommand(t, "diff", "-json", path, edited)
+	assert.Equal(t, 1, code)
+
+	var patch ecs.Patch
+	require.NoError(t, json.Unmarshal([]byte(out), &patch))
+	world := loadWorld(t, path)
+	require.NoError(t, world.ApplyPatch(&patch))
+	divergence, err := ecs.CompareWorlds(world, loadWorld(t, edited))
+	require.NoError(t, err)
+	assert.Nil(t, divergence)
+}
+
+func TestDiffPrintsControllerChanges(t *testing.T) {
+	dir := tempDir(t)
+	path := writeWorld(t, dir)
+	world := loadWorld(t, path)
+	world.SetPlayer(world.GetEntity(rockID))
+	data, err := json.Marshal(world)
+	require.NoError(t, err)
+	edited := filepath.Join(dir, "edited.json")
+	require.NoError(t, ioutil.WriteFile(edited, data, 0o644))
+
+	out, _, code := runCommand(t, "diff", path, edited)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, `--- `+path+`
++++ `+edited+`
+~ controller player: `+rockID.String()+`
+`, out)
+
+	out, _, code = runCommand(t, "diff", "-json", path, edited)
+	assert.Equal(t, 1, code)
+	var patch ecs.Patch
+	require.NoError(t, json.Unmarshal([]byte(out), &patch))
+	require.Len(t, patch.Controllers, 1)
+	assert.Equal(t, rockID, *patch.Controllers[0].Entity)
+}
+
+func TestDiffOfMissingSaveFails(t *testing.T) {
+	path := writeWorld(t, tempDir(t))
+
+	_, stderr, code := runCommand(t, "diff", path, filepath.Join(filepath.Dir(path), "missing.json"))
+	assert.Equal(t, 2, code)
+	assert.NotEmpty(t, stderr)
+}
+
+func TestDiffPrintsDisabledEntities(t *testing.T) {
+	dir := tempDir(t)
+	path := writeWorld(t, dir)
+	world := loadWorld(t, path)
+	world.SetEntityEnabled(world.GetEntity(rockID), false)
+	data, err := json.Marshal(world)
+	require.NoError(t, err)
+	edited := filepath.Join(dir, "edited.json")
+	require.NoError(t, ioutil.WriteFile(edited, data, 0o644))
+
+	out, _, code := runCommand(t, "diff", path, edited)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, `--- `+path+`
++++ `+edited+`
+~ entity `+rockID.String()+`
+    ~ disabled: true
+`, out)
+
+	out, _, code = runCommand(t, "list", edited)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, playerID.String()+"  Position, Name\n"+rockID.String()+" (disabled)  Position\n", out)
+}
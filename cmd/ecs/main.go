@@ -0,0 +1,199 @@
+// Command ecs inspects and edits world and entity saves written by World.Save, Entity.Save or json.Marshal, in any
+// format. Components are identified by their registered names, so the tool works without the game's component types.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/liamg/ecs"
+)
+
+const usage = `usage: ecs <command> [flags] <args>
+
+commands:
+  list [-type TYPE] FILE                     list entities and their component types
+  show [-type TYPE] FILE UUID                print the components of an entity
+  count FILE                                 count components by type
+  set [-index N] [-o OUT] FILE UUID TYPE PATH VALUE
+                                             set a field of a component, where PATH is a JSON Pointer such as /x
+  delete [-o OUT] FILE UUID...               delete entities
+  convert [-format FORMAT] [-compress] IN OUT
+                                             convert a save to json, yaml or binary
+  diff [-json] A B                           compare two saves entity by entity, exiting with status 1 if they
+                                             differ
+
+Edited saves are written back to FILE unless -o is given. Signatures are not checked, and are removed from edited
+saves.
+`
+
+// errUsage is returned when a command is given invalid arguments.
+var errUsage = errors.New("invalid arguments")
+
+// exitError is returned by commands which exit with a particular status. The error, if any, is printed.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+type command func(args []string, stdout io.Writer) error
+
+var commands = map[string]command{
+	"list":    listCommand,
+	"show":    showCommand,
+	"count":   countCommand,
+	"set":     setCommand,
+	"delete":  deleteCommand,
+	"convert": convertCommand,
+	"diff":    diffCommand,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "ecs: unknown command '%s'\n\n%s", args[0], usage)
+		return 2
+	}
+	if err := cmd(args[1:], stdout); err != nil {
+		var exit *exitError
+		if errors.As(err, &exit) {
+			if exit.err != nil {
+				fmt.Fprintf(stderr, "ecs %s: %s\n", args[0], exit.err)
+			}
+			return exit.code
+		}
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(stderr, "ecs %s: %s\n\n%s", args[0], err, usage)
+			return 2
+		}
+		fmt.Fprintf(stderr, "ecs %s: %s\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// parse parses the flags of a command, and checks the number of positional arguments which follow them.
+func parse(flags *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	flags.SetOutput(ioutil.Discard)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	positional := flags.Args()
+	if len(positional) < min || (max >= 0 && len(positional) > max) {
+		return nil, fmt.Errorf("%w: unexpected number of arguments", errUsage)
+	}
+	return positional, nil
+}
+
+func readDocument(path string) (*ecs.Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return ecs.ReadDocument(f)
+}
+
+// writeDocument atomically replaces the file at path with the document, keeping the permissions of any existing file.
+func writeDocument(path string, doc *ecs.Document, options ecs.SaveOptions) error {
+	buf := bytes.NewBuffer(nil)
+	if err := doc.Write(buf, options); err != nil {
+		return err
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// temporary files are created with mode 0600
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func parseUUID(s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return id, fmt.Errorf("%w: '%s' is not a UUID", errUsage, s)
+	}
+	return id, nil
+}
+
+func findEntity(doc *ecs.Document, s string) (*ecs.DocumentEntity, error) {
+	id, err := parseUUID(s)
+	if err != nil {
+		return nil, err
+	}
+	e := doc.Entity(id)
+	if e == nil {
+		return nil, fmt.Errorf("entity %s not found", id)
+	}
+	return e, nil
+}
+
+func parseFormat(name string) (ecs.SaveFormat, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return ecs.FormatJSON, nil
+	case "yaml", "yml":
+		return ecs.FormatYAML, nil
+	case "binary", "bin":
+		return ecs.FormatBinary, nil
+	}
+	return 0, fmt.Errorf("%w: unknown format '%s'", errUsage, name)
+}
+
+func componentTypes(e *ecs.DocumentEntity) []string {
+	types := make([]string, 0, len(e.Components))
+	for _, c := range e.Components {
+		types = append(types, c.Type)
+	}
+	return types
+}
+
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
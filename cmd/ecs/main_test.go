@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/liamg/ecs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Position struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type Name struct {
+	Value string `json:"value"`
+}
+
+func init() {
+	ecs.RegisterComponent(&Position{})
+	ecs.RegisterComponent(&Name{})
+}
+
+var (
+	playerID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	rockID   = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "ecs-cmd")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+// writeWorld saves a world with a named player and a rock, using json.Marshal.
+func writeWorld(t *testing.T, dir string) string {
+	world := ecs.NewWorld(5)
+	player := &ecs.Entity{UUID: playerID, Store: &ecs.ComponentStore{}}
+	player.Add(&Position{X: 1, Y: 2})
+	player.Add(&Name{Value: "hero"})
+	rock := &ecs.Entity{UUID: rockID, Store: &ecs.ComponentStore{}}
+	rock.Add(&Position{X: 3, Y: 4})
+	world.AddEntity(player)
+	world.AddEntity(rock)
+	world.SetPlayer(player)
+
+	data, err := json.Marshal(world)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "world.json")
+	require.NoError(t, ioutil.WriteFile(path, data, 0o644))
+	return path
+}
+
+func runCommand(t *testing.T, args ...string) (string, string, int) {
+	stdout, stderr := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	code := run(args, stdout, stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func loadWorld(t *testing.T, path string) *ecs.World {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	world := ecs.NewWorld(0)
+	require.NoError(t, world.Load(f))
+	return world
+}
+
+func TestList(t *testing.T) {
+	path := writeWorld(t, tempDir(t))
+
+	out, _, code := runCommand(t, "list", path)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, playerID.String()+"  Position, Name\n"+rockID.String()+"  Position\n", out)
+
+	out, _, code = runCommand(t, "list", "-type", "Name", path)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, playerID.String()+"  Position, Name\n", out)
+}
+
+func TestShow(t *testing.T) {
+	path := writeWorld(t, tempDir(t))
+
+	out, _, code := runCommand(t, "show", "-type", "Position", path, playerID.String())
+	assert.Equal(t, 0, code)
+	assert.Equal(t, `entity `+playerID.String()+`
+  Position
+    {
+      "x": 1,
+      "y": 2
+    }
+`, out)
+
+	_, stderr, code := runCommand(t, "show", path, uuid.New().String())
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "not found")
+}
+
+func TestCount(t *testing.T) {
+	path := writeWorld(t, tempDir(t))
+
+	out, _, code := runCommand(t, "count", path)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, `TYPE      COMPONENTS  ENTITIES
+Name      1           1
+Position  2           2
+total                 2
+`, out)
+}
+
+func TestSet(t *testing.T) {
+	path := writeWorld(t, tempDir(t))
+
+	_, stderr, code := runCommand(t, "set", path, rockID.String(), "Position", "/x", "10")
+	require.Equal(t, 0, code, stderr)
+	_, stderr, code = runCommand(t, "set", path, playerID.String(), "Name", "/value", "villain")
+	require.Equal(t, 0, code, stderr)
+
+	world := loadWorld(t, path)
+	assert.Equal(t, []interface{}{&Position{X: 10, Y: 4}}, world.GetEntity(rockID).Store.List())
+	assert.Equal(t, &Name{Value: "villain"}, world.GetEntity(playerID).Store.List()[1])
+
+	_, _, code = runCommand(t, "set", path, rockID.String(), "Name", "/value", "rock")
+	assert.Equal(t, 1, code)
+}
+
+func TestSetKeepsFileMode(t *testing.T) {
+	path := writeWorld(t, tempDir(t))
+	require.NoError(t, os.Chmod(path, 0o640))
+
+	_, stderr, code := runCommand(t, "set", path, rockID.String(), "Position", "/x", "10")
+	require.Equal(t, 0, code, stderr)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+}
+
+func TestSaveManagerFilesCanBeEdited(t *testing.T) {
+	dir := tempDir(t)
+	manager := ecs.NewSaveManager(dir, ecs.SaveOptions{Format: ecs.FormatBinary, Compress: true})
+	require.NoError(t, manager.Save(loadWorld(t, writeWorld(t, dir)), 1))
+	path := filepath.Join(dir, "slot-1.sav")
+
+	out, stderr, code := runCommand(t, "list", path)
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, playerID.String()+"  Position, Name\n"+rockID.String()+"  Position\n", out)
+
+	_, stderr, code = runCommand(t, "set", path, rockID.String(), "Position", "/x", "10")
+	require.Equal(t, 0, code, stderr)
+
+	// the file is still a save which the manager can load
+	world := ecs.NewWorld(0)
+	require.NoError(t, manager.Load(world, 1))
+	assert.Equal(t, []interface{}{&Position{X: 10, Y: 4}}, world.GetEntity(rockID).Store.List())
+	header, err := ecs.ReadSaveHeader(path)
+	require.NoError(t, err)
+	assert.Equal(t, "slot-1", header.Name)
+	assert.Equal(t, ecs.FormatBinary, header.Format)
+}
+
+func TestDelete(t *testing.T) {
+	dir := tempDir(t)
+	path := writeWorld(t, dir)
+	output := filepath.Join(dir, "edited.json")
+
+	_, stderr, code := runCommand(t, "delete", "-o", output, path, playerID.String())
+	require.Equal(t, 0, code, stderr)
+
+	assert.Len(t, loadWorld(t, path).GetEntities(), 2)
+	edited := loadWorld(t, output)
+	assert.Len(t, edited.GetEntities(), 1)
+	assert.Nil(t, edited.Player())
+}
+
+func TestConvert(t *testing.T) {
+	dir := tempDir(t)
+	path := writeWorld(t, dir)
+
+	for _, name := range []string{"world.yaml", "world.bin", "world.gz"} {
+		output := filepath.Join(dir, name)
+		args := []string{"convert", path, output}
+		if name == "world.gz" {
+			args = []string{"convert", "-format", "binary", "-compress", path, output}
+		}
+		_, stderr, code := runCommand(t, args...)
+		require.Equal(t, 0, code, stderr)
+
+		divergence, err := ecs.CompareWorlds(loadWorld(t, path), loadWorld(t, output))
+		require.NoError(t, err)
+		assert.Nil(t, divergence)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "world.yaml"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "turn: 5\n"))
+}
+
+func TestInvalidArguments(t *testing.T) {
+	_, stderr, code := runCommand(t)
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "usage")
+
+	_, _, code = runCommand(t, "explode")
+	assert.Equal(t, 2, code)
+
+	_, _, code = runCommand(t, "show", "world.json")
+	assert.Equal(t, 2, code)
+
+	_, _, code = runCommand(t, "show", "world.json", "not-a-uuid")
+	assert.Equal(t, 1, code)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	// marker is the comment which marks a struct as a component.
+	marker = "//ecs:component"
+	// libraryPath is the import path of the ecs package.
+	libraryPath = "github.com/liamg/ecs"
+)
+
+type component struct {
+	Name       string
+	Repeatable bool
+}
+
+type packageInfo struct {
+	Name string
+	// Qualifier is prepended to identifiers from the ecs package. It is empty when generating for the ecs package
+	// itself.
+	Qualifier  string
+	Components []component
+}
+
+// scan finds the components marked in the package in dir. The previously generated file is ignored.
+func scan(dir, output string) (*packageInfo, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return info.Name() != output && !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+
+	info := &packageInfo{Qualifier: "ecs."}
+	for name, pkg := range pkgs {
+		info.Name = name
+		for _, file := range pkg.Files {
+			components, err := fileComponents(file)
+			if err != nil {
+				return nil, err
+			}
+			info.Components = append(info.Components, components...)
+		}
+	}
+	// files are visited in map order, so sort for stable output
+	sort.Slice(info.Components, func(i, j int) bool {
+		return info.Components[i].Name < info.Components[j].Name
+	})
+	if importPath, err := packageImportPath(dir); err == nil && importPath == libraryPath {
+		info.Qualifier = ""
+	}
+	return info, nil
+}
+
+func fileComponents(file *ast.File) ([]component, error) {
+	var components []component
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			structType, isStruct := typeSpec.Type.(*ast.StructType)
+			if !isStruct {
+				continue
+			}
+			doc := typeSpec.Doc
+			if doc == nil && len(gen.Specs) == 1 {
+				doc = gen.Doc
+			}
+			c, ok := markedComponent(typeSpec.Name.Name, doc)
+			if !ok {
+				continue
+			}
+			// the accessor method is named after the component, so it cannot share a name with a field
+			for _, field := range structType.Fields.List {
+				for _, name := range field.Names {
+					if name.Name == c.Name {
+						return nil, fmt.Errorf("component %s has a field named %s, which clashes with its accessor method", c.Name, c.Name)
+					}
+				}
+			}
+			components = append(components, c)
+		}
+	}
+	return components, nil
+}
+
+func markedComponent(name string, doc *ast.CommentGroup) (component, bool) {
+	if doc == nil {
+		return component{}, false
+	}
+	for _, comment := range doc.List {
+		if !strings.HasPrefix(comment.Text, marker) {
+			continue
+		}
+		options := strings.Fields(strings.TrimPrefix(comment.Text, marker))
+		c := component{Name: name}
+		for _, option := range options {
+			if option == "repeatable" {
+				c.Repeatable = true
+			}
+		}
+		return c, true
+	}
+	return component{}, false
+}
+
+// packageImportPath works out the import path of the package in dir from the nearest go.mod.
+func packageImportPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for root := abs; ; root = filepath.Dir(root) {
+		if module, err := modulePath(filepath.Join(root, "go.mod")); err == nil {
+			rel, err := filepath.Rel(root, abs)
+			if err != nil {
+				return "", err
+			}
+			return path.Join(module, filepath.ToSlash(rel)), nil
+		}
+		if filepath.Dir(root) == root {
+			return "", fmt.Errorf("no go.mod found for %s", dir)
+		}
+	}
+}
+
+func modulePath(goMod string) (string, error) {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no module directive", goMod)
+}
+
+var sourceTemplate = template.Must(template.New("source").Parse(`// Code generated by ecsgen. DO NOT EDIT.
+
+package {{ .Name }}
+{{ if .Qualifier }}
+import "github.com/liamg/ecs"
+{{ end }}
+{{- range .Components }}
+// Has{{ .Name }} is implemented by components which provide a *{{ .Name }}.
+type Has{{ .Name }} interface {
+	{{ .Name }}() *{{ .Name }}
+}
+
+// {{ .Name }} returns the component itself, so that *{{ .Name }} implements Has{{ .Name }}.
+func (c *{{ .Name }}) {{ .Name }}() *{{ .Name }} {
+	return c
+}
+
+// Get{{ .Name }} returns the entity's {{ .Name }} component, or nil if it has none.
+func Get{{ .Name }}(e *{{ $.Qualifier }}Entity) *{{ .Name }} {
+	if c, ok := e.Component((*Has{{ .Name }})(nil)).(Has{{ .Name }}); ok {
+		return c.{{ .Name }}()
+	}
+	return nil
+}
+{{ end }}
+func init() {
+{{- range .Components }}
+	{{ $.Qualifier }}{{ if .Repeatable }}RegisterRepeatableComponent{{ else }}RegisterComponent{{ end }}(&{{ .Name }}{})
+{{- end }}
+}
+`))
+
+// generate renders the generated file for a package.
+func generate(pkg *packageInfo) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := sourceTemplate.Execute(buf, pkg); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exampleDir = "internal/example"
+
+func TestGeneratedExampleIsUpToDate(t *testing.T) {
+	pkg, err := scan(exampleDir, "ecs_gen.go")
+	require.NoError(t, err)
+	assert.Equal(t, []component{
+		{Name: "Position"},
+		{Name: "Tag", Repeatable: true},
+		{Name: "Velocity"},
+	}, pkg.Components)
+
+	generated, err := generate(pkg)
+	require.NoError(t, err)
+	committed, err := ioutil.ReadFile(filepath.Join(exampleDir, "ecs_gen.go"))
+	require.NoError(t, err)
+	assert.Equal(t, string(committed), string(generated), "run go generate ./cmd/ecsgen/...")
+}
+
+func TestGeneratingForTheLibraryDoesNotImportIt(t *testing.T) {
+	generated, err := generate(&packageInfo{
+		Name:       "ecs",
+		Components: []component{{Name: "Position"}},
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, string(generated), "import")
+	assert.Contains(t, string(generated), "func GetPosition(e *Entity) *Position {")
+	assert.Contains(t, string(generated), "\tRegisterComponent(&Position{})\n")
+}
+
+func TestLibraryImportPathIsDetected(t *testing.T) {
+	importPath, err := packageImportPath("../..")
+	require.NoError(t, err)
+	assert.Equal(t, libraryPath, importPath)
+
+	importPath, err = packageImportPath(exampleDir)
+	require.NoError(t, err)
+	assert.Equal(t, libraryPath+"/cmd/ecsgen/internal/example", importPath)
+}
+
+func writePackage(t *testing.T, source string) string {
+	dir, err := ioutil.TempDir("", "ecsgen")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "components.go"), []byte(source), 0o644))
+	return dir
+}
+
+func TestRunWritesGeneratedFile(t *testing.T) {
+	dir := writePackage(t, "package game\n\n//ecs:component\ntype Health struct {\n\tHP int\n}\n")
+
+	require.NoError(t, run(dir, "ecs_gen.go"))
+	generated, err := ioutil.ReadFile(filepath.Join(dir, "ecs_gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(generated), "func GetHealth(e *ecs.Entity) *Health {")
+
+	// the generated file is ignored when scanning again
+	require.NoError(t, run(dir, "ecs_gen.go"))
+}
+
+func TestFieldsNamedAfterTheirComponentAreRejected(t *testing.T) {
+	dir := writePackage(t, "package game\n\n//ecs:component\ntype Health struct {\n\tHealth int\n}\n")
+
+	assert.Error(t, run(dir, "ecs_gen.go"))
+}
+
+func TestPackagesWithoutComponentsAreRejected(t *testing.T) {
+	dir := writePackage(t, "package game\n\ntype Health struct {\n\tHP int\n}\n")
+
+	assert.Error(t, run(dir, "ecs_gen.go"))
+}
@@ -0,0 +1,26 @@
+// Package example holds components used to test ecsgen. The generated ecs_gen.go is checked by the tests of ecsgen.
+package example
+
+//go:generate go run github.com/liamg/ecs/cmd/ecsgen
+
+//ecs:component
+type Position struct {
+	X, Y int
+}
+
+// Velocity is the change in position per turn.
+//
+//ecs:component
+type Velocity struct {
+	DX, DY int
+}
+
+//ecs:component repeatable
+type Tag struct {
+	Name string
+}
+
+// NotAComponent is not marked, so nothing is generated for it.
+type NotAComponent struct {
+	Value int
+}
@@ -0,0 +1,65 @@
+// Code generated by ecsgen. DO NOT EDIT.
+
+package example
+
+import "github.com/liamg/ecs"
+
+// HasPosition is implemented by components which provide a *Position.
+type HasPosition interface {
+	Position() *Position
+}
+
+// Position returns the component itself, so that *Position implements HasPosition.
+func (c *Position) Position() *Position {
+	return c
+}
+
+// GetPosition returns the entity's Position component, or nil if it has none.
+func GetPosition(e *ecs.Entity) *Position {
+	if c, ok := e.Component((*HasPosition)(nil)).(HasPosition); ok {
+		return c.Position()
+	}
+	return nil
+}
+
+// HasTag is implemented by components which provide a *Tag.
+type HasTag interface {
+	Tag() *Tag
+}
+
+// Tag returns the component itself, so that *Tag implements HasTag.
+func (c *Tag) Tag() *Tag {
+	return c
+}
+
+// GetTag returns the entity's Tag component, or nil if it has none.
+func GetTag(e *ecs.Entity) *Tag {
+	if c, ok := e.Component((*HasTag)(nil)).(HasTag); ok {
+		return c.Tag()
+	}
+	return nil
+}
+
+// HasVelocity is implemented by components which provide a *Velocity.
+type HasVelocity interface {
+	Velocity() *Velocity
+}
+
+// Velocity returns the component itself, so that *Velocity implements HasVelocity.
+func (c *Velocity) Velocity() *Velocity {
+	return c
+}
+
+// GetVelocity returns the entity's Velocity component, or nil if it has none.
+func GetVelocity(e *ecs.Entity) *Velocity {
+	if c, ok := e.Component((*HasVelocity)(nil)).(HasVelocity); ok {
+		return c.Velocity()
+	}
+	return nil
+}
+
+func init() {
+	ecs.RegisterComponent(&Position{})
+	ecs.RegisterRepeatableComponent(&Tag{})
+	ecs.RegisterComponent(&Velocity{})
+}
@@ -0,0 +1,24 @@
+package example
+
+import (
+	"testing"
+
+	"github.com/liamg/ecs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratedHelpers(t *testing.T) {
+	e := ecs.NewEntity()
+	position := &Position{X: 1, Y: 2}
+	e.Add(position)
+
+	assert.Same(t, position, GetPosition(e))
+	assert.Nil(t, GetVelocity(e))
+
+	var accessor *HasPosition
+	assert.Equal(t, position, e.Component(accessor))
+
+	component, err := ecs.ComponentFromName("Velocity")
+	assert.NoError(t, err)
+	assert.IsType(t, &Velocity{}, component)
+}
@@ -0,0 +1,48 @@
+// Command ecsgen generates component boilerplate. It is intended to be run by go generate:
+//
+//	//go:generate go run github.com/liamg/ecs/cmd/ecsgen
+//
+// It scans the package in the current directory for struct types marked with an //ecs:component comment, and writes
+// ecs_gen.go containing, for each component:
+//
+//   - an accessor interface, e.g. HasPosition, for use with Entity.Component and System.RequiredTypes
+//   - the accessor method, e.g. func (c *Position) Position() *Position
+//   - a typed helper, e.g. func GetPosition(e *ecs.Entity) *Position
+//   - a RegisterComponent call, made from init
+//
+// Components marked //ecs:component repeatable are registered with RegisterRepeatableComponent instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the package to scan")
+	output := flag.String("output", "ecs_gen.go", "name of the generated file, within the package directory")
+	flag.Parse()
+
+	if err := run(*dir, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "ecsgen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, output string) error {
+	pkg, err := scan(dir, output)
+	if err != nil {
+		return err
+	}
+	if len(pkg.Components) == 0 {
+		return fmt.Errorf("no components found in %s: mark component structs with an //ecs:component comment", dir)
+	}
+	source, err := generate(pkg)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, output), source, 0o644)
+}
@@ -0,0 +1,60 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// ComponentCodec can be implemented by components which are badly served by JSON, such as large tile maps or
+// bitfields. When a component implements ComponentCodec, saves use its encoding in place of JSON.
+type ComponentCodec interface {
+	EncodeComponent() ([]byte, error)
+	DecodeComponent(data []byte) error
+}
+
+// Codec encodes and decodes components of a particular type. Codecs are registered with RegisterComponentWithCodec,
+// and are useful for third-party types which cannot implement ComponentCodec.
+type Codec interface {
+	Encode(component interface{}) ([]byte, error)
+	Decode(data []byte, component interface{}) error
+}
+
+// encodingCodec marks a saved component whose data is a base64 JSON string containing the output of a codec.
+const encodingCodec = "codec"
+
+var componentCodecs = make(map[reflect.Type]Codec)
+
+// RegisterComponentWithCodec registers a component as RegisterComponent does, and sets the codec used to save it. A
+// registered codec is preferred over the component's own ComponentCodec implementation.
+func RegisterComponentWithCodec(component interface{}, codec Codec) {
+	componentCodecs[DefaultRegistry.register(component)] = codec
+}
+
+// encodeComponent encodes a component with its codec if it has one, or as JSON otherwise.
+func encodeComponent(component interface{}) (data []byte, codec bool, err error) {
+	if c, ok := componentCodecs[reflect.TypeOf(component).Elem()]; ok {
+		data, err = c.Encode(component)
+		return data, true, err
+	}
+	if c, ok := component.(ComponentCodec); ok {
+		data, err = c.EncodeComponent()
+		return data, true, err
+	}
+	data, err = json.Marshal(component)
+	return data, false, err
+}
+
+// decodeComponent populates a component with data produced by encodeComponent.
+func decodeComponent(component interface{}, data []byte, codec bool) error {
+	if !codec {
+		return json.Unmarshal(data, component)
+	}
+	if c, ok := componentCodecs[reflect.TypeOf(component).Elem()]; ok {
+		return c.Decode(data, component)
+	}
+	if c, ok := component.(ComponentCodec); ok {
+		return c.DecodeComponent(data)
+	}
+	return fmt.Errorf("%s was saved with a codec but does not have one", componentName(component))
+}
@@ -0,0 +1,119 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type BitfieldComponent struct {
+	Bits []bool
+}
+
+func (c *BitfieldComponent) EncodeComponent() ([]byte, error) {
+	data := make([]byte, (len(c.Bits)+7)/8+1)
+	data[0] = byte(len(c.Bits) % 8)
+	for i, bit := range c.Bits {
+		if bit {
+			data[1+i/8] |= 1 << (i % 8)
+		}
+	}
+	return data, nil
+}
+
+func (c *BitfieldComponent) DecodeComponent(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("empty bitfield")
+	}
+	size := (len(data)-1)*8 - (8-int(data[0]))%8
+	c.Bits = make([]bool, size)
+	for i := range c.Bits {
+		c.Bits[i] = data[1+i/8]&(1<<(i%8)) != 0
+	}
+	return nil
+}
+
+// GridComponent stands in for a third-party type which cannot implement ComponentCodec itself.
+type GridComponent struct {
+	Cells []byte
+}
+
+type gridCodec struct{}
+
+func (gridCodec) Encode(component interface{}) ([]byte, error) {
+	return component.(*GridComponent).Cells, nil
+}
+
+func (gridCodec) Decode(data []byte, component interface{}) error {
+	component.(*GridComponent).Cells = data
+	return nil
+}
+
+func init() {
+	RegisterComponent(&BitfieldComponent{})
+	RegisterComponentWithCodec(&GridComponent{}, gridCodec{})
+}
+
+func buildCodecEntity() *Entity {
+	e := NewEntity()
+	e.Add(&BitfieldComponent{Bits: []bool{true, false, true, true, false, false, false, false, true, true}})
+	e.Add(&GridComponent{Cells: []byte{1, 2, 3, 4}})
+	e.Add(&TestComponent{X: 5})
+	return e
+}
+
+func TestCodecsArePreferredForJSON(t *testing.T) {
+	e := buildCodecEntity()
+
+	data, err := json.Marshal(e)
+	require.NoError(t, err)
+
+	var raw struct {
+		Components []savedComponent `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	require.Len(t, raw.Components, 3)
+	assert.Equal(t, encodingCodec, raw.Components[0].Encoding)
+	assert.Equal(t, encodingCodec, raw.Components[1].Encoding)
+	assert.Equal(t, `"AQIDBA=="`, string(raw.Components[1].Data))
+	assert.Equal(t, "", raw.Components[2].Encoding)
+	assert.NotContains(t, string(data), `"encoding":""`)
+
+	var loaded Entity
+	require.NoError(t, json.Unmarshal(data, &loaded))
+	assert.Equal(t, e, &loaded)
+}
+
+func TestCodecsArePreferredForBinary(t *testing.T) {
+	e := buildCodecEntity()
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, e.Save(buf, SaveOptions{Format: FormatBinary}))
+	assert.True(t, bytes.Contains(buf.Bytes(), []byte{4, 1, 2, 3, 4}))
+
+	loaded, err := LoadEntity(buf)
+	require.NoError(t, err)
+	assert.Equal(t, e, loaded)
+}
+
+func TestCodecComponentsCanBePatched(t *testing.T) {
+	a := NewWorld(0)
+	e := buildCodecEntity()
+	a.AddEntity(e)
+
+	b := NewWorld(0)
+	data, err := json.Marshal(a)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, b))
+	b.GetEntity(e.ID()).Store.components[1].Inner.(*GridComponent).Cells[0] = 9
+
+	patch, err := Diff(a, b)
+	require.NoError(t, err)
+	require.NoError(t, a.ApplyPatch(patch))
+
+	assert.Equal(t, []byte{9, 2, 3, 4}, e.Store.components[1].Inner.(*GridComponent).Cells)
+}
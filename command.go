@@ -0,0 +1,108 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Command is a player intent, such as "move north" or "pick up item". Commands are queued with World.QueueCommand
+// and applied at the start of the next update, before any system runs. Commands must be struct pointers, and must be
+// registered with RegisterCommand so that they can be recorded and replayed.
+type Command interface {
+	Apply(w *World) error
+}
+
+// CommandError is reported when a command fails to apply.
+type CommandError struct {
+	Command Command
+	Turn    int64
+	Err     error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("command %T failed on turn %d: %s", e.Command, e.Turn, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+var registeredCommands []reflect.Type
+
+func RegisterCommand(command Command) {
+
+	t := reflect.TypeOf(command)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for _, cmd := range registeredCommands {
+		if t.Name() == cmd.Name() {
+			panic(fmt.Sprintf("%s is already registered", cmd.Name()))
+		}
+	}
+
+	registeredCommands = append(registeredCommands, t)
+}
+
+func CommandFromName(name string) (Command, error) {
+	for _, cmd := range registeredCommands {
+		if cmd.Name() == name {
+			if command, ok := reflect.New(cmd).Interface().(Command); ok {
+				return command, nil
+			}
+			return nil, fmt.Errorf("command '%s' does not implement Command", name)
+		}
+	}
+
+	return nil, fmt.Errorf("command '%s' was not found in the registry", name)
+}
+
+// QueueCommand queues a command to be applied at the start of the next update.
+func (w *World) QueueCommand(command Command) {
+	w.commands = append(w.commands, command)
+}
+
+// applyCommands is the command phase of an update. Failed commands are reported as a *CommandError.
+func (w *World) applyCommands() []Command {
+	commands := w.commands
+	w.commands = nil
+	for _, command := range commands {
+		if err := command.Apply(w); err != nil {
+			w.reportError(&CommandError{
+				Command: command,
+				Turn:    w.turn,
+				Err:     err,
+			})
+		}
+		w.applyDeferred(false)
+	}
+	return commands
+}
+
+func marshalCommand(command Command) (savedComponent, error) {
+	data, err := json.Marshal(command)
+	if err != nil {
+		return savedComponent{}, err
+	}
+	t := reflect.TypeOf(command)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return savedComponent{
+		Type: t.Name(),
+		Data: data,
+	}, nil
+}
+
+func unmarshalCommand(saved savedComponent) (Command, error) {
+	command, err := CommandFromName(saved.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(saved.Data, command); err != nil {
+		return nil, err
+	}
+	return command, nil
+}
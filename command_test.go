@@ -0,0 +1,81 @@
+package ecs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MoveCommand struct {
+	Entity uuid.UUID `json:"entity"`
+	DX     int       `json:"dx"`
+}
+
+func (c *MoveCommand) Apply(w *World) error {
+	e := w.GetEntity(c.Entity)
+	if e == nil {
+		return errors.New("no such entity")
+	}
+	var testable *Testable
+	e.Component(testable).(Testable).TestComponent().X += c.DX
+	w.UseTurn()
+	return nil
+}
+
+func init() {
+	RegisterCommand(&MoveCommand{})
+}
+
+type ComponentReadingSystem struct {
+	TestSystem
+	seen []int
+}
+
+func (s *ComponentReadingSystem) Update(w *World, p *Entity) {
+	s.TestSystem.Update(w, p)
+	for _, e := range s.addedEntities {
+		var testable *Testable
+		s.seen = append(s.seen, e.Component(testable).(Testable).TestComponent().X)
+	}
+}
+
+func TestQueuedCommandsAreAppliedBeforeSystems(t *testing.T) {
+	world := NewWorld(0)
+	system := &ComponentReadingSystem{}
+	world.AddSystem(system, false)
+
+	e := NewEntity()
+	e.Add(&TestComponent{X: 1})
+	world.AddEntity(e)
+
+	world.QueueCommand(&MoveCommand{Entity: e.ID(), DX: 2})
+	require.NoError(t, world.TryUpdate())
+	require.NoError(t, world.TryUpdate())
+
+	assert.Equal(t, []int{3, 3}, system.seen)
+	assert.Equal(t, int64(1), world.GetTurn())
+}
+
+func TestFailedCommandIsReported(t *testing.T) {
+	world := NewWorld(0)
+	world.QueueCommand(&MoveCommand{Entity: uuid.New()})
+
+	err := world.TryUpdate()
+	require.Error(t, err)
+
+	var cmdErr *CommandError
+	require.True(t, errors.As(err, &cmdErr))
+	assert.IsType(t, &MoveCommand{}, cmdErr.Command)
+}
+
+func TestCommandFromName(t *testing.T) {
+	cmd, err := CommandFromName("MoveCommand")
+	require.NoError(t, err)
+	assert.IsType(t, &MoveCommand{}, cmd)
+
+	_, err = CommandFromName("NotACommand")
+	assert.Error(t, err)
+}
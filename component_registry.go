@@ -5,30 +5,128 @@ import (
 	"reflect"
 )
 
-var registeredComponents []reflect.Type
+// Registry maps the names that components are saved under to their types, so that saves can be loaded. Worlds use
+// DefaultRegistry unless given another with World.SetRegistry. Codecs set with RegisterComponentWithCodec are not
+// held by a registry, as saving does not use one, and so apply to every world.
+type Registry struct {
+	types []reflect.Type
+	// repeatable are the types registered with RegisterRepeatable
+	repeatable map[reflect.Type]bool
+}
 
-func RegisterComponent(component interface{}) {
+// DefaultRegistry is the registry used by RegisterComponent and ComponentFromName.
+var DefaultRegistry = &Registry{}
+
+// builtinComponents are the components provided by the library, such as RNG. They are saved under names prefixed with
+// "ecs." so that they cannot collide with a game's own components, and every registry holds them.
+var builtinComponents = make(map[reflect.Type]bool)
+
+// registerBuiltin registers a component provided by the library. It must only be called from init.
+func registerBuiltin(component interface{}) {
+	builtinComponents[reflect.TypeOf(component).Elem()] = true
+	DefaultRegistry.Register(component)
+}
+
+// typeName returns the name a component type is registered and saved under.
+func typeName(t reflect.Type) string {
+	if builtinComponents[t] {
+		return "ecs." + t.Name()
+	}
+	return t.Name()
+}
+
+// NewRegistry creates a registry holding only the components provided by the library.
+func NewRegistry() *Registry {
+	r := &Registry{}
+	for _, t := range DefaultRegistry.types {
+		if builtinComponents[t] {
+			r.types = append(r.types, t)
+		}
+	}
+	return r
+}
+
+// Register adds a component type to the registry. It panics if a type with the same name is already registered.
+func (r *Registry) Register(component interface{}) {
+	r.register(component)
+}
+
+func (r *Registry) register(component interface{}) reflect.Type {
 
 	t := reflect.TypeOf(component)
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
 
-	for _, comp := range registeredComponents {
-		if t.Name() == comp.Name() {
-			panic(fmt.Sprintf("%s is already registered", comp.Name()))
+	for _, comp := range r.types {
+		if typeName(t) == typeName(comp) {
+			panic(fmt.Sprintf("%s is already registered", typeName(comp)))
 		}
 	}
 
-	registeredComponents = append(registeredComponents, t)
+	r.types = append(r.types, t)
+	return t
 }
 
-func ComponentFromName(name string) (interface{}, error) {
-	for _, comp := range registeredComponents {
-		if comp.Name() == name {
+// RegisterRepeatable registers a component as Register does, and allows an entity to hold more than one component of
+// its type when loaded strictly. See World.SetStrictLoading.
+func (r *Registry) RegisterRepeatable(component interface{}) {
+	t := r.register(component)
+	if r.repeatable == nil {
+		r.repeatable = make(map[reflect.Type]bool)
+	}
+	r.repeatable[t] = true
+}
+
+// Registered returns true if the type of the component is registered under its name.
+func (r *Registry) Registered(component interface{}) bool {
+	t := reflect.TypeOf(component)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for _, comp := range r.types {
+		if comp == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ComponentFromName creates a new component of the type registered under the given name.
+func (r *Registry) ComponentFromName(name string) (interface{}, error) {
+	for _, comp := range r.types {
+		if typeName(comp) == name {
 			return reflect.New(comp).Interface(), nil
 		}
 	}
 
 	return nil, fmt.Errorf("component '%s' was not found in the registry", name)
 }
+
+func RegisterComponent(component interface{}) {
+	DefaultRegistry.Register(component)
+}
+
+// RegisterRepeatableComponent registers a component as RegisterComponent does, and allows an entity to hold more than
+// one component of its type when loaded strictly. See World.SetStrictLoading.
+func RegisterRepeatableComponent(component interface{}) {
+	DefaultRegistry.RegisterRepeatable(component)
+}
+
+func ComponentFromName(name string) (interface{}, error) {
+	return DefaultRegistry.ComponentFromName(name)
+}
+
+// SetRegistry sets the registry used to load the world's saves, and by TransferEntity to check that the world can
+// save the entities it is given.
+func (w *World) SetRegistry(registry *Registry) {
+	w.registry = registry
+}
+
+// Registry returns the registry used by the world, which is DefaultRegistry unless another has been set.
+func (w *World) Registry() *Registry {
+	if w.registry == nil {
+		return DefaultRegistry
+	}
+	return w.registry
+}
@@ -1,7 +1,10 @@
 package ecs
 
 import (
+	"bytes"
+	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -14,3 +17,98 @@ func TestComponentDeserialisation(t *testing.T) {
 		t.Fatalf("Component does not implement interface")
 	}
 }
+
+func TestLibraryComponentsDoNotCollideWithGameComponents(t *testing.T) {
+	// RNG has the same name as a component provided by the library
+	type RNG struct{}
+	registry := NewRegistry()
+	registry.Register(&RNG{})
+	component, err := registry.ComponentFromName("RNG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := component.(*RNG); !ok {
+		t.Fatalf("expected the game's RNG, got %T", component)
+	}
+	if _, err := registry.ComponentFromName("ecs.RNG"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorldsCanLoadWithTheirOwnRegistry(t *testing.T) {
+	registry := NewRegistry()
+	if registry.Registered(&TestComponent{}) || !registry.Registered(&RNG{}) {
+		t.Fatal("new registries should only hold the library's components")
+	}
+	registry.Register(&TestComponent{})
+	if !registry.Registered(&TestComponent{}) {
+		t.Fatal("registry does not hold the registered component")
+	}
+
+	world := NewWorld(0)
+	world.SetRegistry(registry)
+	if world.Registry() != registry || NewWorld(0).Registry() != DefaultRegistry {
+		t.Fatal("world does not use its registry")
+	}
+	e := NewEntity()
+	e.Add(&TestComponent{X: 1})
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := readEntity(data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadEntity(raw, registry, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadEntity(raw, NewRegistry(), false); err == nil {
+		t.Fatal("expected unregistered component to fail to load")
+	}
+}
+
+func TestRepeatableComponentsBelongToTheirRegistry(t *testing.T) {
+	type Marker struct{}
+	repeatable, single := NewRegistry(), NewRegistry()
+	repeatable.RegisterRepeatable(&Marker{})
+	single.Register(&Marker{})
+
+	data := worldJSON(`{"type":"Marker","data":{}}`, `{"type":"Marker","data":{}}`)
+	world := strictWorld()
+	world.SetRegistry(repeatable)
+	if err := world.Load(strings.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	world = strictWorld()
+	world.SetRegistry(single)
+	if err := world.Load(strings.NewReader(data)); err == nil {
+		t.Fatal("expected a repeated component to be rejected by a registry where it is not repeatable")
+	}
+}
+
+func TestEntitiesCanBeLoadedWithARegistry(t *testing.T) {
+	// Loot is only registered with the registry, not with DefaultRegistry
+	type Loot struct {
+		Gold int `json:"gold"`
+	}
+	registry := NewRegistry()
+	registry.Register(&Loot{})
+
+	e := NewEntity()
+	e.Add(&Loot{Gold: 5})
+	buf := bytes.NewBuffer(nil)
+	if err := e.Save(buf, SaveOptions{Format: FormatBinary}); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := registry.LoadEntityStrict(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loot, ok := loaded.Store.List()[0].(*Loot); !ok || loot.Gold != 5 {
+		t.Fatalf("unexpected component %#v", loaded.Store.List()[0])
+	}
+	if _, err := LoadEntity(bytes.NewReader(buf.Bytes())); err == nil {
+		t.Fatal("expected a component missing from DefaultRegistry to fail to load")
+	}
+}
@@ -0,0 +1,148 @@
+package ecs
+
+import "sync"
+
+// worldSync holds the state used by a world in concurrent mode. See World.SetConcurrent.
+type worldSync struct {
+	enabled bool
+	// exclusive is held whilst the world is updating, applying a structural change or running a View
+	exclusive sync.Mutex
+	// entities guards the entity list of the world and the member lists of its systems
+	entities sync.RWMutex
+	// pending guards the fields below
+	pending sync.Mutex
+	// busy is true whilst exclusive is held
+	busy      bool
+	deferred  []func()
+	submitted []func(w *World)
+}
+
+// SetConcurrent enables or disables concurrent mode, which allows a world to be used from more than one goroutine,
+// such as a network or UI goroutine reading state whilst another calls Run. It must be called before the world is
+// shared.
+//
+// In concurrent mode:
+//
+//   - AddEntity, RemoveEntity, ClearEntities, AddComponentToEntity and RemoveComponentFromEntity are synchronised with
+//     each other and with updates. Changes made whilst the world is updating, whether by systems or by other
+//     goroutines, are deferred until the current system or command returns, and are then applied by the goroutine
+//     running the update. A system therefore does not see its own structural changes until its next update.
+//   - GetEntity, GetEntities and SystemEntities may be called from any goroutine. GetEntities and SystemEntities
+//     return copies of the lists, but not of the entities in them.
+//   - Components are not synchronised and there is no snapshot of them: reading a component from another goroutine
+//     outside View is a data race, even on an entity returned by GetEntity or GetEntities. View waits until the world
+//     is between updates.
+//   - Mutations other than structural changes should be made from other goroutines with Submit.
+func (w *World) SetConcurrent(enabled bool) {
+	w.concurrency.enabled = enabled
+}
+
+// Concurrent returns true if the world is in concurrent mode.
+func (w *World) Concurrent() bool {
+	return w.concurrency.enabled
+}
+
+// View calls fn whilst no update or structural change is in progress, so that it sees a consistent world. Structural
+// changes made by fn are applied when it returns. In concurrent mode, View must not be called from a system or
+// command, as it would wait forever for the update to finish.
+func (w *World) View(fn func(w *World)) {
+	w.exclusively(func() { fn(w) })
+}
+
+// Submit queues a mutation to be applied by the goroutine running the world, between updates: fn is called at the
+// start of the next update, before commands are applied. Submit may be called from any goroutine.
+func (w *World) Submit(fn func(w *World)) {
+	w.concurrency.pending.Lock()
+	defer w.concurrency.pending.Unlock()
+	w.concurrency.submitted = append(w.concurrency.submitted, fn)
+}
+
+// exclusively calls fn with exclusive access to the world. In concurrent mode, structural changes made whilst fn
+// runs are deferred until it returns.
+func (w *World) exclusively(fn func()) {
+	if !w.concurrency.enabled {
+		fn()
+		return
+	}
+	w.concurrency.exclusive.Lock()
+	defer w.concurrency.exclusive.Unlock()
+	w.concurrency.pending.Lock()
+	w.concurrency.busy = true
+	w.concurrency.pending.Unlock()
+	defer func() {
+		// only reached with changes outstanding if fn panicked, in which case they are applied by the next caller
+		w.concurrency.pending.Lock()
+		w.concurrency.busy = false
+		w.concurrency.pending.Unlock()
+	}()
+	fn()
+	w.applyDeferred(true)
+}
+
+// synchronise makes a structural change. In concurrent mode, changes made whilst the world is busy are deferred until
+// it is not.
+func (w *World) synchronise(change func()) {
+	if !w.concurrency.enabled {
+		change()
+		return
+	}
+	w.concurrency.pending.Lock()
+	if w.concurrency.busy {
+		w.concurrency.deferred = append(w.concurrency.deferred, change)
+		w.concurrency.pending.Unlock()
+		return
+	}
+	w.concurrency.pending.Unlock()
+	w.exclusively(change)
+}
+
+// applyDeferred applies structural changes which were deferred whilst the world was busy, including any they defer in
+// turn. If release is true, the world stops being busy once there are none left.
+func (w *World) applyDeferred(release bool) {
+	for {
+		w.concurrency.pending.Lock()
+		changes := w.concurrency.deferred
+		w.concurrency.deferred = nil
+		if len(changes) == 0 && release {
+			w.concurrency.busy = false
+		}
+		w.concurrency.pending.Unlock()
+		if len(changes) == 0 {
+			return
+		}
+		for _, change := range changes {
+			change()
+		}
+	}
+}
+
+// applySubmitted calls the functions queued with Submit.
+func (w *World) applySubmitted() {
+	w.concurrency.pending.Lock()
+	submitted := w.concurrency.submitted
+	w.concurrency.submitted = nil
+	w.concurrency.pending.Unlock()
+	for _, fn := range submitted {
+		fn(w)
+		w.applyDeferred(false)
+	}
+}
+
+// snapshot returns a copy of a list of entities, such as the members of a system, so that it can be used whilst the
+// world changes.
+func (w *World) snapshot(entities *[]*Entity) []*Entity {
+	if w.concurrency.enabled {
+		w.concurrency.entities.RLock()
+		defer w.concurrency.entities.RUnlock()
+	}
+	snapshot := make([]*Entity, len(*entities))
+	copy(snapshot, *entities)
+	return snapshot
+}
+
+// modify replaces a list of entities, such as the members of a system, whilst no other goroutine is reading it.
+func (w *World) modify(entities *[]*Entity, fn func([]*Entity) []*Entity) {
+	w.concurrency.entities.Lock()
+	defer w.concurrency.entities.Unlock()
+	*entities = fn(*entities)
+}
@@ -0,0 +1,224 @@
+package ecs
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type SpawningSystem struct {
+	TestSystem
+	seen []int
+}
+
+func (s *SpawningSystem) Update(w *World, p *Entity) {
+	s.TestSystem.Update(w, p)
+	e := NewEntity()
+	w.AddEntity(e)
+	w.AddComponentToEntity(&TestComponent{}, e)
+	s.seen = append(s.seen, len(w.GetEntities()))
+}
+
+// TogglingSystem disables and enables its targets on alternate updates.
+type TogglingSystem struct {
+	TestSystem
+	targets []*Entity
+	enabled bool
+}
+
+func (s *TogglingSystem) Update(w *World, p *Entity) {
+	s.TestSystem.Update(w, p)
+	for _, e := range s.targets {
+		w.SetEntityEnabled(e, s.enabled)
+	}
+	s.enabled = !s.enabled
+}
+
+func TestConcurrentWorldCanBeUsedWhilstUpdating(t *testing.T) {
+	world := NewWorld(0)
+	world.SetConcurrent(true)
+	incrementer := &IncrementingSystem{}
+	world.AddSystem(incrementer, false)
+	world.AddSystem(&SpawningSystem{}, false)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			for _, e := range world.GetEntities() {
+				_ = e.ID()
+			}
+			world.View(func(w *World) {
+				var testable *Testable
+				for _, e := range w.GetEntities() {
+					if c := e.Component(testable); c != nil {
+						_ = c.(Testable).TestComponent().X
+					}
+				}
+			})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			e := NewEntity()
+			world.AddEntity(e)
+			world.AddComponentToEntity(&TestComponent{}, e)
+			world.RemoveEntity(e)
+			world.Submit(func(w *World) {
+				w.AddEntity(NewEntity())
+			})
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, world.TryUpdate())
+	}
+	close(done)
+	wg.Wait()
+
+	require.NoError(t, world.TryUpdate())
+	assert.Len(t, world.GetEntities(), 101+50)
+	assert.Len(t, incrementer.addedEntities, 101+50)
+	assert.Len(t, incrementer.removedEntities, 50)
+}
+
+func TestStructuralChangesBySystemsAreDeferredInConcurrentMode(t *testing.T) {
+	world := NewWorld(0)
+	world.SetConcurrent(true)
+	spawner := &SpawningSystem{}
+	world.AddSystem(spawner, false)
+	counter := &TestSystem{}
+	world.AddSystem(counter, false)
+
+	require.NoError(t, world.TryUpdate())
+	require.NoError(t, world.TryUpdate())
+
+	// each spawned entity appears once the spawning system has returned
+	assert.Equal(t, []int{0, 1}, spawner.seen)
+	assert.Len(t, counter.addedEntities, 2)
+	assert.Len(t, world.GetEntities(), 2)
+}
+
+func TestStructuralChangesBySystemsAreImmediateByDefault(t *testing.T) {
+	world := NewWorld(0)
+	spawner := &SpawningSystem{}
+	world.AddSystem(spawner, false)
+
+	require.NoError(t, world.TryUpdate())
+	require.NoError(t, world.TryUpdate())
+
+	assert.Equal(t, []int{1, 2}, spawner.seen)
+}
+
+func TestSubmittedMutationsAreAppliedAtTheStartOfTheNextUpdate(t *testing.T) {
+	world := NewWorld(0)
+	world.SetConcurrent(true)
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+
+	var order []string
+	world.QueueCommand(&recordingCommand{order: &order})
+	world.Submit(func(w *World) {
+		order = append(order, "submitted")
+		e := NewEntity()
+		e.Add(&TestComponent{})
+		w.AddEntity(e)
+	})
+	assert.Empty(t, world.GetEntities())
+
+	require.NoError(t, world.TryUpdate())
+	assert.Equal(t, []string{"submitted", "command"}, order)
+	assert.Len(t, system.addedEntities, 1)
+}
+
+type spawningCommand struct{}
+
+func (c *spawningCommand) Apply(w *World) error {
+	e := NewEntity()
+	e.Add(&TestComponent{})
+	w.AddEntity(e)
+	return nil
+}
+
+func TestStructuralChangesByCommandsAreSeenBySystemsInConcurrentMode(t *testing.T) {
+	world := NewWorld(0)
+	world.SetConcurrent(true)
+	world.AddSystem(&IncrementingSystem{}, false)
+	world.QueueCommand(&spawningCommand{})
+
+	require.NoError(t, world.TryUpdate())
+
+	// the spawned entity was given to the system before it was updated
+	var testable *Testable
+	require.Len(t, world.GetEntities(), 1)
+	assert.Equal(t, 1, world.GetEntities()[0].Component(testable).(Testable).TestComponent().X)
+}
+
+type recordingCommand struct {
+	order *[]string
+}
+
+func (c *recordingCommand) Apply(w *World) error {
+	*c.order = append(*c.order, "command")
+	return nil
+}
+
+func TestGetEntitiesReturnsACopyInConcurrentMode(t *testing.T) {
+	world := NewWorld(0)
+	world.SetConcurrent(true)
+	world.AddEntity(NewEntity())
+
+	entities := world.GetEntities()
+	world.AddEntity(NewEntity())
+
+	assert.Len(t, entities, 1)
+	assert.Len(t, world.GetEntities(), 2)
+}
+
+func TestEntitiesCanBeListedWhilstSystemsDisableThem(t *testing.T) {
+	world := NewWorld(0)
+	world.SetConcurrent(true)
+	toggler := &TogglingSystem{}
+	for i := 0; i < 100; i++ {
+		e := NewEntity()
+		world.AddEntity(e)
+		toggler.targets = append(toggler.targets, e)
+	}
+	world.AddSystem(toggler, false)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			for _, e := range world.GetEntities() {
+				_ = e.ID()
+			}
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, world.TryUpdate())
+	}
+	close(done)
+	wg.Wait()
+
+	assert.Len(t, world.GetEntities(), 100)
+	assert.Empty(t, world.DisabledEntities())
+}
@@ -0,0 +1,87 @@
+package ecs
+
+// PlayerController is the name of the controller bound by World.SetPlayer.
+const PlayerController = "player"
+
+// Controller binds an entity to a source of input, such as a keyboard, a gamepad or a network peer. Any number of
+// controllers can be bound to a world, allowing for hot-seat and local co-op games.
+type Controller struct {
+	Name   string
+	Entity *Entity
+	// Source is whatever the game uses to read input for this controller. It is not used by the world itself.
+	Source interface{}
+}
+
+// BindController binds an entity to a named controller, replacing any existing controller with the same name.
+func (w *World) BindController(name string, entity *Entity, source interface{}) *Controller {
+	controller := &Controller{
+		Name:   name,
+		Entity: entity,
+		Source: source,
+	}
+	for i, c := range w.controllers {
+		if c.Name == name {
+			w.controllers[i] = controller
+			return controller
+		}
+	}
+	w.controllers = append(w.controllers, controller)
+	return controller
+}
+
+// UnbindController removes the named controller from the world.
+func (w *World) UnbindController(name string) {
+	for i, c := range w.controllers {
+		if c.Name == name {
+			w.controllers = append(w.controllers[:i], w.controllers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Controller returns the named controller, or nil.
+func (w *World) Controller(name string) *Controller {
+	for _, c := range w.controllers {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Controllers returns all controllers bound to the world, in the order they were bound. The list is copied, so that
+// changing it does not change the world's bindings.
+func (w *World) Controllers() []*Controller {
+	controllers := make([]*Controller, len(w.controllers))
+	copy(controllers, w.controllers)
+	return controllers
+}
+
+// Player returns the entity passed to System.Update. This is the entity bound to PlayerController if there is one,
+// otherwise the entity of the first bound controller, otherwise nil. Whilst an inactive level is being simulated,
+// systems are passed nil instead (see SetLevelSimulation).
+func (w *World) Player() *Entity {
+	if c := w.Controller(PlayerController); c != nil {
+		return c.Entity
+	}
+	if len(w.controllers) > 0 {
+		return w.controllers[0].Entity
+	}
+	return nil
+}
+
+func (w *World) updateSystem(reg *systemRegistration) {
+	var player *Entity
+	if w.simulating == "" {
+		player = w.Player()
+	}
+	if cs, ok := reg.system.(ContextSystem); ok {
+		cs.UpdateWithContext(&UpdateContext{
+			World:       w,
+			Player:      player,
+			Controllers: w.Controllers(),
+		})
+		return
+	}
+	reg.system.Update(w, player)
+}
@@ -0,0 +1,99 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type PlayerRecordingSystem struct {
+	TestSystem
+	players []*Entity
+}
+
+func (s *PlayerRecordingSystem) Update(w *World, p *Entity) {
+	s.TestSystem.Update(w, p)
+	s.players = append(s.players, p)
+}
+
+type ContextRecordingSystem struct {
+	TestSystem
+	contexts []*UpdateContext
+}
+
+func (s *ContextRecordingSystem) UpdateWithContext(ctx *UpdateContext) {
+	s.contexts = append(s.contexts, ctx)
+}
+
+func TestLegacyPlayerIsPassedToSystems(t *testing.T) {
+	world := NewWorld(0)
+
+	system := &PlayerRecordingSystem{}
+	world.AddSystem(system, false)
+
+	player := NewEntity()
+	world.SetPlayer(player)
+	require.NoError(t, world.TryUpdate())
+
+	require.Len(t, system.players, 1)
+	assert.Equal(t, player, system.players[0])
+}
+
+func TestFirstControllerIsUsedAsPlayerWhenNoneIsSet(t *testing.T) {
+	world := NewWorld(0)
+
+	first := NewEntity()
+	second := NewEntity()
+	world.BindController("p1", first, nil)
+	world.BindController("p2", second, nil)
+
+	assert.Equal(t, first, world.Player())
+
+	world.SetPlayer(second)
+	assert.Equal(t, second, world.Player())
+}
+
+func TestContextSystemsReceiveAllControllers(t *testing.T) {
+	world := NewWorld(0)
+
+	system := &ContextRecordingSystem{}
+	world.AddSystem(system, false)
+
+	first := NewEntity()
+	second := NewEntity()
+	world.BindController("p1", first, "keyboard")
+	world.BindController("p2", second, "gamepad")
+
+	require.NoError(t, world.TryUpdate())
+
+	assert.Equal(t, 0, system.updateCount)
+	require.Len(t, system.contexts, 1)
+
+	ctx := system.contexts[0]
+	assert.Equal(t, world, ctx.World)
+	assert.Equal(t, first, ctx.Player)
+	require.Len(t, ctx.Controllers, 2)
+	assert.Equal(t, second, ctx.Controllers[1].Entity)
+	assert.Equal(t, "gamepad", ctx.Controllers[1].Source)
+}
+
+func TestControllersCanBeReboundAndUnbound(t *testing.T) {
+	world := NewWorld(0)
+
+	first := NewEntity()
+	second := NewEntity()
+	world.BindController("p1", first, nil)
+	world.BindController("p1", second, nil)
+
+	require.Len(t, world.Controllers(), 1)
+	assert.Equal(t, second, world.Controller("p1").Entity)
+
+	// the list returned by Controllers belongs to the caller
+	world.Controllers()[0] = &Controller{Name: "p1", Entity: first}
+	assert.Equal(t, second, world.Controller("p1").Entity)
+
+	world.UnbindController("p1")
+	assert.Nil(t, world.Controller("p1"))
+	assert.Nil(t, world.Player())
+}
@@ -0,0 +1,546 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Patch describes the changes needed to turn one world into another. It is produced by Diff, can be serialised as
+// JSON, and can be applied to a world with World.ApplyPatch.
+type Patch struct {
+	Turn      int64           `json:"turn"`
+	Added     []EntityPatch   `json:"added,omitempty"`
+	Removed   []uuid.UUID     `json:"removed,omitempty"`
+	Changed   []EntityPatch   `json:"changed,omitempty"`
+	Resources ComponentsPatch `json:"resources"`
+	// Controllers are the controllers which were bound, rebound or unbound. Controllers are matched by name.
+	Controllers []ControllerPatch `json:"controllers,omitempty"`
+}
+
+// Empty returns true if applying the patch would not change anything other than the turn.
+func (p *Patch) Empty() bool {
+	return len(p.Added) == 0 && len(p.Removed) == 0 && len(p.Changed) == 0 && p.Resources.Empty() &&
+		len(p.Controllers) == 0
+}
+
+// ControllerPatch describes a change to a single controller binding.
+type ControllerPatch struct {
+	Name string `json:"name"`
+	// Entity is the UUID of the entity the controller is now bound to, or nil if the controller was unbound.
+	Entity *uuid.UUID `json:"entity,omitempty"`
+}
+
+// EntityPatch describes the changes to the components of a single entity.
+type EntityPatch struct {
+	UUID uuid.UUID `json:"uuid"`
+	// Disabled is set if the entity was disabled or enabled, or for added entities, if the entity is disabled.
+	Disabled *bool `json:"disabled,omitempty"`
+	ComponentsPatch
+}
+
+// ComponentsPatch describes the changes to a list of components, i.e. those of an entity or the world's resources.
+type ComponentsPatch struct {
+	Added   []ComponentPatch `json:"added,omitempty"`
+	Removed []ComponentPatch `json:"removed,omitempty"`
+	Changed []ComponentPatch `json:"changed,omitempty"`
+}
+
+func (p *ComponentsPatch) Empty() bool {
+	return len(p.Added) == 0 && len(p.Removed) == 0 && len(p.Changed) == 0
+}
+
+// ComponentPatch describes a single added, removed or changed component. Components are identified by their registered
+// type name and their index amongst the components of the same type.
+type ComponentPatch struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+	// Encoding and Data are the complete saved component for added components.
+	Encoding string          `json:"encoding,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	// Fields are the field level changes for changed components.
+	Fields []FieldDelta `json:"fields,omitempty"`
+}
+
+// FieldDelta is a change to a single JSON value within a component. Path is a JSON Pointer (RFC 6901) to the value.
+// From is omitted if the value was added, and To is omitted if the value was removed.
+type FieldDelta struct {
+	Path string          `json:"path"`
+	From json.RawMessage `json:"from,omitempty"`
+	To   json.RawMessage `json:"to,omitempty"`
+}
+
+// Diff returns a patch which turns world a into world b. Entities are matched by UUID.
+func Diff(a, b *World) (*Patch, error) {
+	frozenA, err := freeze(a)
+	if err != nil {
+		return nil, err
+	}
+	frozenB, err := freeze(b)
+	if err != nil {
+		return nil, err
+	}
+	return diffFrozen(frozenA, frozenB)
+}
+
+// DiffDocuments returns a patch which turns document a into document b, in the same way as Diff. The component types
+// do not need to be registered.
+func DiffDocuments(a, b *Document) (*Patch, error) {
+	return diffFrozen(a.frozen(), b.frozen())
+}
+
+func diffFrozen(a, b *frozenWorld) (*Patch, error) {
+	patch := &Patch{
+		Turn: b.frozenTurn,
+	}
+
+	var err error
+	if patch.Resources, err = diffComponents(a.frozenResources, b.frozenResources); err != nil {
+		return nil, err
+	}
+
+	fromA := make(map[uuid.UUID]rawEntity)
+	for _, e := range a.entities {
+		fromA[e.id] = e
+	}
+	fromB := make(map[uuid.UUID]rawEntity)
+	for _, e := range b.entities {
+		fromB[e.id] = e
+	}
+
+	for _, e := range sortedByID(a.entities) {
+		if _, ok := fromB[e.id]; !ok {
+			patch.Removed = append(patch.Removed, e.id)
+		}
+	}
+
+	for _, e := range sortedByID(b.entities) {
+		existing, ok := fromA[e.id]
+		components, err := diffComponents(existing.components, e.components)
+		if err != nil {
+			return nil, err
+		}
+		entityPatch := EntityPatch{
+			UUID:            e.id,
+			ComponentsPatch: components,
+		}
+		if (!ok && e.disabled) || (ok && e.disabled != existing.disabled) {
+			disabled := e.disabled
+			entityPatch.Disabled = &disabled
+		}
+		switch {
+		case !ok:
+			patch.Added = append(patch.Added, entityPatch)
+		case !components.Empty() || entityPatch.Disabled != nil:
+			patch.Changed = append(patch.Changed, entityPatch)
+		}
+	}
+
+	patch.Controllers = diffControllers(a.frozenControllers, b.frozenControllers)
+	return patch, nil
+}
+
+func diffControllers(a, b []savedController) []ControllerPatch {
+	fromA := make(map[string]uuid.UUID)
+	for _, c := range a {
+		fromA[c.Name] = c.Entity
+	}
+	fromB := make(map[string]uuid.UUID)
+	for _, c := range b {
+		fromB[c.Name] = c.Entity
+	}
+
+	var patches []ControllerPatch
+	for _, c := range a {
+		if _, ok := fromB[c.Name]; !ok {
+			patches = append(patches, ControllerPatch{Name: c.Name})
+		}
+	}
+	for _, c := range b {
+		if id, ok := fromA[c.Name]; !ok || id != c.Entity {
+			entity := c.Entity
+			patches = append(patches, ControllerPatch{Name: c.Name, Entity: &entity})
+		}
+	}
+	return patches
+}
+
+// ApplyPatch applies a patch produced by Diff. Components are added and removed with AddComponentToEntity and
+// RemoveComponentFromEntity, so systems are kept up to date. Changed components are updated in place. Every change is
+// checked before any is made, so if an error is returned the world is left as it was.
+func (w *World) ApplyPatch(patch *Patch) error {
+	changes, err := w.planPatch(patch)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		change()
+	}
+	w.turn = patch.Turn
+	return nil
+}
+
+// planPatch checks that a patch can be applied to the world, returning the changes which apply it in order.
+func (w *World) planPatch(patch *Patch) ([]func(), error) {
+	var changes []func()
+
+	removed := make(map[uuid.UUID]bool)
+	for _, id := range patch.Removed {
+		e := w.GetEntity(id)
+		if e == nil || removed[id] {
+			return nil, fmt.Errorf("cannot remove entity %s: entity not found", id)
+		}
+		removed[id] = true
+		changes = append(changes, func() { w.RemoveEntity(e) })
+	}
+
+	resourceChanges, err := w.planComponents(w.resources, nil, patch.Resources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to patch resources: %w", err)
+	}
+	changes = append(changes, resourceChanges...)
+
+	for _, entityPatch := range patch.Changed {
+		e := w.GetEntity(entityPatch.UUID)
+		if e == nil || removed[entityPatch.UUID] {
+			return nil, fmt.Errorf("cannot patch entity %s: entity not found", entityPatch.UUID)
+		}
+		componentChanges, err := w.planComponents(e.Store, e, entityPatch.ComponentsPatch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to patch entity %s: %w", e.ID(), err)
+		}
+		changes = append(changes, componentChanges...)
+		if entityPatch.Disabled != nil {
+			enabled := !*entityPatch.Disabled
+			changes = append(changes, func() { w.SetEntityEnabled(e, enabled) })
+		}
+	}
+
+	adding := make(map[uuid.UUID]*Entity)
+	for _, entityPatch := range patch.Added {
+		if (w.GetEntity(entityPatch.UUID) != nil && !removed[entityPatch.UUID]) || adding[entityPatch.UUID] != nil {
+			return nil, fmt.Errorf("cannot add entity %s: entity already exists", entityPatch.UUID)
+		}
+		e := &Entity{
+			UUID:     entityPatch.UUID,
+			Disabled: entityPatch.Disabled != nil && *entityPatch.Disabled,
+			Store:    &ComponentStore{},
+		}
+		for _, added := range entityPatch.Added {
+			component, err := savedComponent{Type: added.Type, Encoding: added.Encoding, Data: added.Data}.load(w.Registry())
+			if err != nil {
+				return nil, err
+			}
+			e.Add(component)
+		}
+		adding[e.UUID] = e
+		changes = append(changes, func() { w.AddEntity(e) })
+	}
+
+	for _, controllerPatch := range patch.Controllers {
+		name := controllerPatch.Name
+		if controllerPatch.Entity == nil {
+			changes = append(changes, func() { w.UnbindController(name) })
+			continue
+		}
+		id := *controllerPatch.Entity
+		e := adding[id]
+		if e == nil && !removed[id] {
+			e = w.GetEntity(id)
+		}
+		if e == nil {
+			return nil, fmt.Errorf("cannot bind controller '%s' to entity %s: entity not found", name, id)
+		}
+		changes = append(changes, func() {
+			// the source of a controller is not saved, so it is kept when the controller is rebound
+			var source interface{}
+			if c := w.Controller(name); c != nil {
+				source = c.Source
+			}
+			w.BindController(name, e, source)
+		})
+	}
+
+	return changes, nil
+}
+
+func sortedByID(entities []rawEntity) []rawEntity {
+	sorted := make([]rawEntity, len(entities))
+	copy(sorted, entities)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].id[:], sorted[j].id[:]) < 0
+	})
+	return sorted
+}
+
+func diffComponents(a, b []rawComponent) (ComponentsPatch, error) {
+	var patch ComponentsPatch
+
+	savedA, err := savedComponentsByType(a)
+	if err != nil {
+		return patch, err
+	}
+	savedB, err := savedComponentsByType(b)
+	if err != nil {
+		return patch, err
+	}
+
+	var types []string
+	for t := range savedA {
+		types = append(types, t)
+	}
+	for t := range savedB {
+		if _, ok := savedA[t]; !ok {
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+
+	for _, t := range types {
+		listA, listB := savedA[t], savedB[t]
+		for i := 0; i < len(listA) || i < len(listB); i++ {
+			switch {
+			case i >= len(listB):
+				patch.Removed = append(patch.Removed, ComponentPatch{Type: t, Index: i})
+			case i >= len(listA):
+				patch.Added = append(patch.Added, ComponentPatch{Type: t, Index: i, Encoding: listB[i].Encoding, Data: listB[i].Data})
+			case !bytes.Equal(listA[i].Data, listB[i].Data):
+				fields, err := diffJSON(listA[i].Data, listB[i].Data)
+				if err != nil {
+					return patch, err
+				}
+				// the data may only differ in formatting or key order
+				if len(fields) > 0 {
+					patch.Changed = append(patch.Changed, ComponentPatch{Type: t, Index: i, Fields: fields})
+				}
+			}
+		}
+	}
+
+	return patch, nil
+}
+
+func savedComponentsByType(components []rawComponent) (map[string][]savedComponent, error) {
+	byType := make(map[string][]savedComponent)
+	for _, c := range components {
+		saved, err := c.saved()
+		if err != nil {
+			return nil, err
+		}
+		byType[saved.Type] = append(byType[saved.Type], saved)
+	}
+	return byType, nil
+}
+
+// componentByTypeIndex returns the nth component in the store with the given type name.
+func componentByTypeIndex(store *ComponentStore, typeName string, index int) (interface{}, error) {
+	var n int
+	for _, c := range store.components {
+		if componentName(c.Inner) != typeName {
+			continue
+		}
+		if n == index {
+			return c.Inner, nil
+		}
+		n++
+	}
+	return nil, fmt.Errorf("component %s[%d] not found", typeName, index)
+}
+
+// patchComponents applies the changes to the store. If the store belongs to an entity, the entity is given so that
+// systems can be kept up to date.
+// planComponents checks that a patch can be applied to the components of a store, returning the changes which apply it
+// in order. e is the entity which owns the store, or nil for resources.
+func (w *World) planComponents(store *ComponentStore, e *Entity, patch ComponentsPatch) ([]func(), error) {
+	var changes []func()
+
+	for _, changed := range patch.Changed {
+		component, err := componentByTypeIndex(store, changed.Type, changed.Index)
+		if err != nil {
+			return nil, err
+		}
+		patched, err := applyFieldDeltas(component, changed.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to patch component %s[%d]: %w", changed.Type, changed.Index, err)
+		}
+		// update the component in place, so that anything holding it sees the change
+		changes = append(changes, func() { reflect.ValueOf(component).Elem().Set(reflect.ValueOf(patched).Elem()) })
+	}
+
+	// find all removed components before removing any, so that indexes remain valid
+	for _, removed := range patch.Removed {
+		component, err := componentByTypeIndex(store, removed.Type, removed.Index)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, func() {
+			if e != nil {
+				w.RemoveComponentFromEntity(component, e)
+			} else {
+				store.Remove(component)
+			}
+		})
+	}
+
+	for _, added := range patch.Added {
+		component, err := savedComponent{Type: added.Type, Encoding: added.Encoding, Data: added.Data}.load(w.Registry())
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, func() {
+			if e != nil {
+				w.AddComponentToEntity(component, e)
+			} else {
+				store.Add(component)
+			}
+		})
+	}
+
+	return changes, nil
+}
+
+func decodeJSONValue(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func diffJSON(a, b []byte) ([]FieldDelta, error) {
+	valueA, err := decodeJSONValue(a)
+	if err != nil {
+		return nil, err
+	}
+	valueB, err := decodeJSONValue(b)
+	if err != nil {
+		return nil, err
+	}
+	var deltas []FieldDelta
+	if err := diffJSONValues("", valueA, valueB, true, true, &deltas); err != nil {
+		return nil, err
+	}
+	return deltas, nil
+}
+
+func diffJSONValues(path string, a, b interface{}, hasA, hasB bool, deltas *[]FieldDelta) error {
+	objA, okA := a.(map[string]interface{})
+	objB, okB := b.(map[string]interface{})
+	if hasA && hasB && okA && okB {
+		var keys []string
+		for k := range objA {
+			keys = append(keys, k)
+		}
+		for k := range objB {
+			if _, ok := objA[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			valueA, inA := objA[k]
+			valueB, inB := objB[k]
+			if err := diffJSONValues(path+"/"+escapePointerToken(k), valueA, valueB, inA, inB, deltas); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if hasA == hasB && reflect.DeepEqual(a, b) {
+		return nil
+	}
+
+	delta := FieldDelta{Path: path}
+	if hasA {
+		data, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		delta.From = data
+	}
+	if hasB {
+		data, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		delta.To = data
+	}
+	*deltas = append(*deltas, delta)
+	return nil
+}
+
+// applyFieldDeltas returns a new component of the same type holding the component's data with the deltas applied.
+// The component itself is not changed.
+func applyFieldDeltas(component interface{}, deltas []FieldDelta) (interface{}, error) {
+	current, err := (&serialisableComponent{Inner: component}).saved()
+	if err != nil {
+		return nil, err
+	}
+	root, err := decodeJSONValue(current.Data)
+	if err != nil {
+		return nil, err
+	}
+	for _, delta := range deltas {
+		if root, err = applyFieldDelta(root, delta); err != nil {
+			return nil, err
+		}
+	}
+	if current.Data, err = json.Marshal(root); err != nil {
+		return nil, err
+	}
+	// decode into a zero value so that removed fields are cleared
+	patched := reflect.New(reflect.TypeOf(component).Elem()).Interface()
+	if err := current.decodeInto(patched); err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
+
+func applyFieldDelta(root interface{}, delta FieldDelta) (interface{}, error) {
+	var to interface{}
+	if delta.To != nil {
+		var err error
+		if to, err = decodeJSONValue(delta.To); err != nil {
+			return nil, err
+		}
+	}
+	if delta.Path == "" {
+		return to, nil
+	}
+
+	tokens := strings.Split(delta.Path[1:], "/")
+	parent := root
+	for i, token := range tokens {
+		obj, ok := parent.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot apply change to %s: parent is not an object", delta.Path)
+		}
+		key := unescapePointerToken(token)
+		if i < len(tokens)-1 {
+			parent = obj[key]
+			continue
+		}
+		if delta.To == nil {
+			delete(obj, key)
+		} else {
+			obj[key] = to
+		}
+	}
+	return root, nil
+}
+
+func escapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func unescapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
@@ -0,0 +1,269 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type NestedComponent struct {
+	Name  string            `json:"name"`
+	Stats map[string]int    `json:"stats,omitempty"`
+	Tags  []string          `json:"tags"`
+	Ref   *NestedComponent  `json:"ref"`
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+func init() {
+	RegisterComponent(&NestedComponent{})
+}
+
+func TestDiffOfIdenticalWorldsIsEmpty(t *testing.T) {
+	a := buildChecksumWorld(false)
+	b := buildChecksumWorld(false)
+
+	patch, err := Diff(a, b)
+	require.NoError(t, err)
+	assert.True(t, patch.Empty())
+}
+
+func TestDiffIgnoresKeyOrderAndFormatting(t *testing.T) {
+	world := buildChecksumWorld(false)
+	world.GetEntities()[0].Add(&NestedComponent{Name: "a", Tags: []string{"x"}, Stats: map[string]int{"b": 1, "a": 2}})
+	data, err := json.Marshal(world)
+	require.NoError(t, err)
+
+	// decoding into maps and encoding again sorts the keys of every object
+	var generic interface{}
+	require.NoError(t, json.Unmarshal(data, &generic))
+	reformatted, err := json.MarshalIndent(generic, "", "    ")
+	require.NoError(t, err)
+	require.NotEqual(t, data, reformatted)
+
+	a, err := ReadDocument(bytes.NewReader(data))
+	require.NoError(t, err)
+	b, err := ReadDocument(bytes.NewReader(reformatted))
+	require.NoError(t, err)
+	patch, err := DiffDocuments(a, b)
+	require.NoError(t, err)
+	assert.True(t, patch.Empty())
+}
+
+func TestDiffReportsEntityAndComponentChanges(t *testing.T) {
+	a := buildChecksumWorld(false)
+	b := buildChecksumWorld(false)
+
+	removed := b.GetEntities()[0]
+	b.RemoveEntity(removed)
+
+	added := NewEntity()
+	added.Add(&TestComponent{X: 77})
+	b.AddEntity(added)
+
+	changed := b.GetEntities()[1]
+	var testable *Testable
+	changed.Component(testable).(Testable).TestComponent().X = 1000
+	changed.Add(&NestedComponent{Name: "new"})
+
+	patch, err := Diff(a, b)
+	require.NoError(t, err)
+
+	require.Len(t, patch.Removed, 1)
+	assert.Equal(t, removed.ID(), patch.Removed[0])
+	require.Len(t, patch.Added, 1)
+	assert.Equal(t, added.ID(), patch.Added[0].UUID)
+	require.Len(t, patch.Changed, 1)
+	assert.Equal(t, changed.ID(), patch.Changed[0].UUID)
+
+	require.Len(t, patch.Changed[0].Changed, 1)
+	assert.Equal(t, "TestComponent", patch.Changed[0].Changed[0].Type)
+	assert.Equal(t, []FieldDelta{{
+		Path: "/X",
+		From: json.RawMessage("1"),
+		To:   json.RawMessage("1000"),
+	}}, patch.Changed[0].Changed[0].Fields)
+	require.Len(t, patch.Changed[0].Added, 1)
+	assert.Equal(t, "NestedComponent", patch.Changed[0].Added[0].Type)
+}
+
+func TestDiffDocumentsMatchesDiff(t *testing.T) {
+	a := buildChecksumWorld(false)
+	b := buildChecksumWorld(false)
+	b.RemoveEntity(b.GetEntities()[0])
+	b.GetEntities()[0].Add(&NestedComponent{Name: "new"})
+	b.UseTurn()
+
+	readDocument := func(w *World) *Document {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, w.Save(buf, SaveOptions{Format: FormatBinary}))
+		doc, err := ReadDocument(buf)
+		require.NoError(t, err)
+		return doc
+	}
+
+	expected, err := Diff(a, b)
+	require.NoError(t, err)
+	actual, err := DiffDocuments(readDocument(a), readDocument(b))
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	assert.False(t, actual.Empty())
+}
+
+func TestPatchCanBeSerialisedAndApplied(t *testing.T) {
+	a := NewWorld(0)
+	base := NewEntity()
+	base.Add(&NestedComponent{
+		Name:  "goblin",
+		Stats: map[string]int{"hp": 10, "str": 3},
+		Tags:  []string{"monster"},
+		Extra: map[string]string{"a/b": "c"},
+		Ref:   &NestedComponent{Name: "inner"},
+	})
+	base.Add(&TestComponent{X: 1})
+	base.Add(&TestComponent{X: 2})
+	a.AddEntity(base)
+
+	data, err := json.Marshal(a)
+	require.NoError(t, err)
+	b := NewWorld(0)
+	require.NoError(t, json.Unmarshal(data, b))
+
+	be := b.GetEntity(base.ID())
+	nested := be.Store.components[0].Inner.(*NestedComponent)
+	nested.Stats["hp"] = 4
+	delete(nested.Stats, "str")
+	nested.Tags = append(nested.Tags, "angry")
+	nested.Extra = nil
+	nested.Ref = nil
+	b.RemoveComponentFromEntity(be.Store.components[2].Inner, be)
+	b.AddEntity(NewEntity())
+	b.UseTurn()
+	b.SetSeed(3)
+
+	patch, err := Diff(a, b)
+	require.NoError(t, err)
+
+	encoded, err := json.Marshal(patch)
+	require.NoError(t, err)
+	var decoded Patch
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+
+	system := &TestSystem{}
+	a.AddSystem(system, false)
+	require.NoError(t, a.ApplyPatch(&decoded))
+
+	divergence, err := CompareWorlds(a, b)
+	require.NoError(t, err)
+	assert.Nil(t, divergence)
+
+	// the patched component should be updated in place
+	assert.Equal(t, 4, nestedOf(base).Stats["hp"])
+	assert.Equal(t, map[string]int{"hp": 4}, nestedOf(base).Stats)
+	assert.Nil(t, nestedOf(base).Ref)
+}
+
+func TestApplyingPatchToWrongWorldFails(t *testing.T) {
+	a := buildChecksumWorld(false)
+	b := buildChecksumWorld(false)
+	b.RemoveEntity(b.GetEntities()[0])
+
+	patch, err := Diff(a, b)
+	require.NoError(t, err)
+
+	assert.Error(t, NewWorld(0).ApplyPatch(patch))
+}
+
+func TestFailedPatchLeavesTheWorldUnchanged(t *testing.T) {
+	a := buildChecksumWorld(false)
+	b := buildChecksumWorld(false)
+	b.RemoveEntity(b.GetEntities()[0])
+	b.GetEntities()[0].Component(IsTestable).(Testable).TestComponent().X = 42
+
+	patch, err := Diff(a, b)
+	require.NoError(t, err)
+	require.NotEmpty(t, patch.Removed)
+	require.NotEmpty(t, patch.Changed)
+	// the last change cannot be applied
+	patch.Added = append(patch.Added, EntityPatch{UUID: uuid.New(), ComponentsPatch: ComponentsPatch{
+		Added: []ComponentPatch{{Type: "UnregisteredComponent", Data: json.RawMessage(`{}`)}},
+	}})
+
+	expected := buildChecksumWorld(false)
+	assert.Error(t, a.ApplyPatch(patch))
+	divergence, err := CompareWorlds(expected, a)
+	require.NoError(t, err)
+	assert.Nil(t, divergence)
+}
+
+func TestPatchesIncludeControllers(t *testing.T) {
+	a := buildChecksumWorld(false)
+	b := buildChecksumWorld(false)
+	b.SetPlayer(b.GetEntities()[1])
+	b.BindController("p2", b.GetEntities()[0], nil)
+
+	patch, err := Diff(a, b)
+	require.NoError(t, err)
+	assert.False(t, patch.Empty())
+	require.NoError(t, a.ApplyPatch(patch))
+	assert.Equal(t, b.Player().ID(), a.Player().ID())
+	require.NotNil(t, a.Controller("p2"))
+	assert.Equal(t, b.GetEntities()[0].ID(), a.Controller("p2").Entity.ID())
+
+	patch, err = Diff(b, buildChecksumWorld(false))
+	require.NoError(t, err)
+	require.NoError(t, a.ApplyPatch(patch))
+	assert.Nil(t, a.Controller("p2"))
+}
+
+func nestedOf(e *Entity) *NestedComponent {
+	for _, c := range e.Store.List() {
+		if nested, ok := c.(*NestedComponent); ok {
+			return nested
+		}
+	}
+	return nil
+}
+
+func TestDiffReportsDisabledEntities(t *testing.T) {
+	a := NewWorld(0)
+	e := NewEntity()
+	e.Add(&TestComponent{X: 1})
+	a.AddEntity(e)
+
+	data, err := json.Marshal(a)
+	require.NoError(t, err)
+	b := NewWorld(0)
+	require.NoError(t, json.Unmarshal(data, b))
+	b.SetEntityEnabled(b.GetEntity(e.ID()), false)
+	added := NewEntity()
+	added.Disabled = true
+	b.AddEntity(added)
+
+	patch, err := Diff(a, b)
+	require.NoError(t, err)
+	require.Len(t, patch.Changed, 1)
+	require.NotNil(t, patch.Changed[0].Disabled)
+	assert.True(t, *patch.Changed[0].Disabled)
+	require.Len(t, patch.Added, 1)
+	require.NotNil(t, patch.Added[0].Disabled)
+
+	system := &TestSystem{}
+	a.AddSystem(system, false)
+	require.NoError(t, a.ApplyPatch(patch))
+	assert.Equal(t, []*Entity{e}, system.removedEntities)
+	assert.True(t, a.GetEntity(added.ID()).Disabled)
+
+	divergence, err := CompareWorlds(a, b)
+	require.NoError(t, err)
+	assert.Nil(t, divergence)
+
+	a.SetEntityEnabled(e, true)
+	divergence, err = CompareWorlds(a, b)
+	require.NoError(t, err)
+	require.NotNil(t, divergence)
+	assert.Equal(t, e.ID(), divergence.Entity)
+}
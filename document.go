@@ -0,0 +1,342 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a save which has been read without loading its components, so that it can be inspected and edited by
+// tools which do not have the component types registered. Component data is kept exactly as it was saved.
+type Document struct {
+	// SingleEntity is true if the document was written by Entity.Save, in which case it holds exactly one entity and
+	// no turn, controllers or resources.
+	SingleEntity bool
+	// ComponentList is true if the document was written by ComponentStore.MarshalJSON or ComponentStore.MarshalYAML,
+	// in which case it holds exactly one entity, with a nil UUID, holding the components.
+	ComponentList bool
+	Turn          int64
+	Controllers   []DocumentController
+	Resources     []*DocumentComponent
+	Entities      []*DocumentEntity
+	// Options are those the document was saved with.
+	Options SaveOptions
+	// Header is the header of a file written by a SaveManager, or nil for a plain save. Write writes the header back,
+	// updated to describe the new contents, so that the file can still be loaded by the manager.
+	Header *SaveHeader
+}
+
+// DocumentController is a controller binding within a Document.
+type DocumentController struct {
+	Name   string
+	Entity uuid.UUID
+}
+
+// DocumentEntity is an entity within a Document.
+type DocumentEntity struct {
+	UUID uuid.UUID
+	// Disabled is true if the entity was disabled with World.SetEntityEnabled.
+	Disabled   bool
+	Components []*DocumentComponent
+}
+
+// DocumentComponent is a component within a Document. Type is the registered name of the component. Data is JSON,
+// unless Codec is true, in which case it is the output of the component's codec.
+type DocumentComponent struct {
+	Type  string
+	Data  []byte
+	Codec bool
+}
+
+// savedFile is either a world or an entity, as saved in JSON or YAML.
+type savedFile struct {
+	savedDocument `yaml:",inline"`
+	UUID          *uuid.UUID       `json:"uuid" yaml:"uuid"`
+	Disabled      bool             `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Components    []savedComponent `json:"components" yaml:"components"`
+}
+
+// ReadDocument reads a world or entity written by World.Save or Entity.Save, in any format, or a list of components
+// written by ComponentStore.MarshalJSON or ComponentStore.MarshalYAML, including saves in files written by a
+// SaveManager. Signatures are not checked.
+func ReadDocument(in io.Reader) (*Document, error) {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{}
+	if bytes.HasPrefix(data, []byte(saveFileMagic)) {
+		if doc.Header, data, err = readSaveFile(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+	}
+	if data, err = verifySigned(data, nil); err != nil {
+		return nil, err
+	}
+	doc.Options.Compress = bytes.HasPrefix(data, gzipMagic)
+	if data, err = decompress(data); err != nil {
+		return nil, err
+	}
+
+	if isBinary(data) {
+		doc.Options.Format = FormatBinary
+		if len(data) > len(binaryMagic)+1 && data[len(binaryMagic)+1] == binaryKindEntity {
+			e, err := readBinaryEntity(data)
+			if err != nil {
+				return nil, err
+			}
+			doc.setEntity(e)
+			return doc, nil
+		}
+		f, err := readBinaryWorld(data)
+		if err != nil {
+			return nil, err
+		}
+		doc.setWorld(f)
+		return doc, nil
+	}
+
+	doc.Options.Format = FormatJSON
+	if isYAML(data) {
+		doc.Options.Format = FormatYAML
+	}
+	if isComponentList(data, doc.Options.Format) {
+		var components []savedComponent
+		if doc.Options.Format == FormatYAML {
+			err = decodeYAML(data, &components, false)
+		} else {
+			err = decodeJSON(data, &components, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		e := rawEntity{components: make([]rawComponent, 0, len(components))}
+		for _, c := range components {
+			raw, err := c.raw()
+			if err != nil {
+				return nil, err
+			}
+			e.components = append(e.components, raw)
+		}
+		doc.ComponentList = true
+		doc.Entities = []*DocumentEntity{documentEntity(e)}
+		return doc, nil
+	}
+
+	var file savedFile
+	if doc.Options.Format == FormatYAML {
+		err = decodeYAML(data, &file, false)
+	} else {
+		err = decodeJSON(data, &file, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if file.UUID != nil {
+		e, err := savedEntity{UUID: *file.UUID, Disabled: file.Disabled, Components: file.Components}.raw()
+		if err != nil {
+			return nil, err
+		}
+		doc.setEntity(e)
+		return doc, nil
+	}
+	f, err := file.frozen()
+	if err != nil {
+		return nil, err
+	}
+	doc.setWorld(f)
+	return doc, nil
+}
+
+// isComponentList reports whether an uncompressed, non-binary save is a list of components rather than a world or
+// entity.
+func isComponentList(data []byte, format SaveFormat) bool {
+	if format == FormatJSON {
+		return bytes.HasPrefix(bytes.TrimSpace(data), []byte("["))
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return false
+	}
+	return node.Kind == yaml.DocumentNode && len(node.Content) == 1 && node.Content[0].Kind == yaml.SequenceNode
+}
+
+func (d *Document) setEntity(e rawEntity) {
+	d.SingleEntity = true
+	d.Entities = []*DocumentEntity{documentEntity(e)}
+}
+
+func (d *Document) setWorld(f *frozenWorld) {
+	d.Turn = f.frozenTurn
+	for _, c := range f.frozenControllers {
+		d.Controllers = append(d.Controllers, DocumentController{Name: c.Name, Entity: c.Entity})
+	}
+	d.Resources = documentComponents(f.frozenResources)
+	d.Entities = make([]*DocumentEntity, 0, len(f.entities))
+	for _, e := range f.entities {
+		d.Entities = append(d.Entities, documentEntity(e))
+	}
+}
+
+func documentEntity(e rawEntity) *DocumentEntity {
+	return &DocumentEntity{
+		UUID:       e.id,
+		Disabled:   e.disabled,
+		Components: documentComponents(e.components),
+	}
+}
+
+func documentComponents(raw []rawComponent) []*DocumentComponent {
+	components := make([]*DocumentComponent, 0, len(raw))
+	for _, c := range raw {
+		components = append(components, &DocumentComponent{Type: c.name, Data: c.data, Codec: c.codec})
+	}
+	return components
+}
+
+func rawComponents(components []*DocumentComponent) []rawComponent {
+	raw := make([]rawComponent, 0, len(components))
+	for _, c := range components {
+		raw = append(raw, rawComponent{name: c.Type, data: c.Data, codec: c.Codec})
+	}
+	return raw
+}
+
+func (e *DocumentEntity) raw() rawEntity {
+	return rawEntity{
+		id:         e.UUID,
+		disabled:   e.Disabled,
+		components: rawComponents(e.Components),
+	}
+}
+
+// Write saves the document using the given options, in the same way as World.Save or Entity.Save. Component lists are
+// written in the layout of ComponentStore.MarshalJSON or ComponentStore.MarshalYAML, and have no binary format.
+func (d *Document) Write(out io.Writer, options SaveOptions) error {
+	if d.Header != nil {
+		body := bytes.NewBuffer(nil)
+		if err := d.writeBody(body, options); err != nil {
+			return err
+		}
+		header := *d.Header
+		header.Turn = d.Turn
+		header.Format = options.Format
+		header.Compressed = options.Compress
+		return writeSaveFile(out, &header, body.Bytes())
+	}
+	return d.writeBody(out, options)
+}
+
+func (d *Document) writeBody(out io.Writer, options SaveOptions) error {
+	if d.ComponentList {
+		if len(d.Entities) != 1 {
+			return fmt.Errorf("component list document must hold exactly one entity, found %d", len(d.Entities))
+		}
+		return writeSave(out, options, func(out io.Writer) error {
+			return writeComponentList(out, options.Format, d.Entities[0].raw().components)
+		})
+	}
+	if d.SingleEntity {
+		if len(d.Entities) != 1 {
+			return fmt.Errorf("entity document must hold exactly one entity, found %d", len(d.Entities))
+		}
+		return writeSave(out, options, func(out io.Writer) error {
+			return writeEntity(out, options.Format, d.Entities[0].raw())
+		})
+	}
+	return NewEncoder(out, options).encode(d.frozen())
+}
+
+func (d *Document) frozen() *frozenWorld {
+	f := &frozenWorld{
+		frozenTurn:      d.Turn,
+		frozenResources: rawComponents(d.Resources),
+		entities:        make([]rawEntity, 0, len(d.Entities)),
+	}
+	for _, c := range d.Controllers {
+		f.frozenControllers = append(f.frozenControllers, savedController{Name: c.Name, Entity: c.Entity})
+	}
+	for _, e := range d.Entities {
+		f.entities = append(f.entities, e.raw())
+	}
+	return f
+}
+
+// Entity returns the entity with the given UUID, or nil if there is no such entity.
+func (d *Document) Entity(id uuid.UUID) *DocumentEntity {
+	for _, e := range d.Entities {
+		if e.UUID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+// RemoveEntity removes the entity with the given UUID, along with any controllers bound to it. It returns false if
+// there is no such entity.
+func (d *Document) RemoveEntity(id uuid.UUID) bool {
+	for i, e := range d.Entities {
+		if e.UUID != id {
+			continue
+		}
+		d.Entities = append(d.Entities[:i], d.Entities[i+1:]...)
+		controllers := d.Controllers[:0]
+		for _, c := range d.Controllers {
+			if c.Entity != id {
+				controllers = append(controllers, c)
+			}
+		}
+		d.Controllers = controllers
+		return true
+	}
+	return false
+}
+
+// Component returns the nth component of the given type, or nil if there is no such component.
+func (e *DocumentEntity) Component(typeName string, index int) *DocumentComponent {
+	var n int
+	for _, c := range e.Components {
+		if c.Type != typeName {
+			continue
+		}
+		if n == index {
+			return c
+		}
+		n++
+	}
+	return nil
+}
+
+// Has returns true if the entity has at least one component of the given type.
+func (e *DocumentEntity) Has(typeName string) bool {
+	return e.Component(typeName, 0) != nil
+}
+
+// Set replaces the JSON value at path, a JSON Pointer (RFC 6901) such as "/position/x", with value. A missing member
+// is added to its object, but the object itself must already exist. Components saved by a codec cannot be edited.
+func (c *DocumentComponent) Set(path string, value json.RawMessage) error {
+	if c.Codec {
+		return fmt.Errorf("%s is saved by a codec and cannot be edited", c.Type)
+	}
+	if path != "" && path[0] != '/' {
+		return fmt.Errorf("invalid path '%s': paths are JSON Pointers and must start with '/'", path)
+	}
+	root, err := decodeJSONValue(c.Data)
+	if err != nil {
+		return err
+	}
+	if root, err = applyFieldDelta(root, FieldDelta{Path: path, To: value}); err != nil {
+		return err
+	}
+	data, err := json.Marshal(root)
+	if err != nil {
+		return err
+	}
+	c.Data = data
+	return nil
+}
@@ -0,0 +1,137 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	for _, options := range saveOptions {
+		t.Run(fmt.Sprintf("%d-%t", options.Format, options.Compress), func(t *testing.T) {
+			world := buildSaveWorld(5)
+			buf := bytes.NewBuffer(nil)
+			require.NoError(t, world.Save(buf, options))
+
+			doc, err := ReadDocument(buf)
+			require.NoError(t, err)
+			assert.False(t, doc.SingleEntity)
+			assert.Equal(t, options, doc.Options)
+			assert.Equal(t, world.GetTurn(), doc.Turn)
+			assert.Len(t, doc.Entities, 5)
+			assert.Equal(t, "TestComponent", doc.Entities[0].Components[0].Type)
+
+			written := bytes.NewBuffer(nil)
+			require.NoError(t, doc.Write(written, options))
+			loaded := NewWorld(0)
+			require.NoError(t, loaded.Load(written))
+			divergence, err := CompareWorlds(world, loaded)
+			require.NoError(t, err)
+			assert.Nil(t, divergence)
+		})
+	}
+}
+
+func TestDocumentReadsMarshalledWorlds(t *testing.T) {
+	world := buildSaveWorld(2)
+	data, err := json.Marshal(world)
+	require.NoError(t, err)
+
+	doc, err := ReadDocument(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, FormatJSON, doc.Options.Format)
+	require.Len(t, doc.Entities, 2)
+	assert.JSONEq(t, `{"X":1}`, string(doc.Entities[1].Component("TestComponent", 0).Data))
+	assert.Equal(t, world.Player().ID(), doc.Controllers[0].Entity)
+}
+
+func TestDocumentOfEntity(t *testing.T) {
+	for _, options := range saveOptions {
+		t.Run(fmt.Sprintf("%d-%t", options.Format, options.Compress), func(t *testing.T) {
+			e := buildCodecEntity()
+			buf := bytes.NewBuffer(nil)
+			require.NoError(t, e.Save(buf, options))
+
+			doc, err := ReadDocument(buf)
+			require.NoError(t, err)
+			assert.True(t, doc.SingleEntity)
+			require.NotNil(t, doc.Entity(e.ID()))
+			assert.True(t, doc.Entity(e.ID()).Component("GridComponent", 0).Codec)
+
+			written := bytes.NewBuffer(nil)
+			require.NoError(t, doc.Write(written, SaveOptions{Format: FormatYAML}))
+			loaded, err := LoadEntity(written)
+			require.NoError(t, err)
+			assert.Equal(t, e, loaded)
+		})
+	}
+}
+
+func TestDocumentOfComponentList(t *testing.T) {
+	for format, marshal := range map[SaveFormat]func(interface{}) ([]byte, error){
+		FormatJSON: json.Marshal,
+		FormatYAML: yaml.Marshal,
+	} {
+		t.Run(fmt.Sprintf("%d", format), func(t *testing.T) {
+			e := buildCodecEntity()
+			data, err := marshal(e.Store)
+			require.NoError(t, err)
+
+			doc, err := ReadDocument(bytes.NewReader(data))
+			require.NoError(t, err)
+			assert.True(t, doc.ComponentList)
+			assert.Equal(t, format, doc.Options.Format)
+			require.Len(t, doc.Entities, 1)
+			assert.True(t, doc.Entities[0].Component("GridComponent", 0).Codec)
+			require.NoError(t, doc.Entities[0].Component("TestComponent", 0).Set("/X", json.RawMessage(`6`)))
+
+			written := bytes.NewBuffer(nil)
+			require.NoError(t, doc.Write(written, doc.Options))
+			loaded := &ComponentStore{}
+			if format == FormatYAML {
+				require.NoError(t, yaml.Unmarshal(written.Bytes(), loaded))
+			} else {
+				require.NoError(t, json.Unmarshal(written.Bytes(), loaded))
+			}
+			var testable *Testable
+			e.Component(testable).(Testable).TestComponent().X = 6
+			assert.Equal(t, e.Store.List(), loaded.List())
+
+			assert.Error(t, doc.Write(bytes.NewBuffer(nil), SaveOptions{Format: FormatBinary}))
+		})
+	}
+}
+
+func TestDocumentComponentsCanBeEdited(t *testing.T) {
+	doc := &Document{SingleEntity: true, Entities: []*DocumentEntity{documentEntity(rawEntity{
+		components: []rawComponent{{name: "NestedComponent", data: []byte(`{"name":"a","tags":[],"ref":null}`)}},
+	})}}
+	component := doc.Entities[0].Component("NestedComponent", 0)
+
+	require.NoError(t, component.Set("/name", json.RawMessage(`"b"`)))
+	require.NoError(t, component.Set("/stats", json.RawMessage(`{"hp":3}`)))
+	require.NoError(t, component.Set("/stats/mp", json.RawMessage(`4`)))
+	assert.JSONEq(t, `{"name":"b","tags":[],"ref":null,"stats":{"hp":3,"mp":4}}`, string(component.Data))
+
+	assert.Error(t, component.Set("name", json.RawMessage(`"c"`)))
+	assert.Error(t, component.Set("/missing/value", json.RawMessage(`1`)))
+	assert.Error(t, (&DocumentComponent{Type: "GridComponent", Codec: true}).Set("/x", json.RawMessage(`1`)))
+}
+
+func TestRemovingDocumentEntityUnbindsControllers(t *testing.T) {
+	world := buildSaveWorld(3)
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, world.Save(buf, SaveOptions{}))
+	doc, err := ReadDocument(buf)
+	require.NoError(t, err)
+
+	assert.True(t, doc.RemoveEntity(world.Player().ID()))
+	assert.False(t, doc.RemoveEntity(world.Player().ID()))
+	assert.Len(t, doc.Entities, 2)
+	assert.Empty(t, doc.Controllers)
+}
@@ -1,18 +1,23 @@
 package ecs
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"reflect"
-	"strings"
 
 	"github.com/google/uuid"
 )
 
 // Entity. See https://en.wikipedia.org/wiki/Entity_component_system
 type Entity struct {
-	UUID  uuid.UUID       `json:"uuid"`
-	Store *ComponentStore `json:"components"`
+	UUID uuid.UUID `json:"uuid" yaml:"uuid"`
+	// Disabled entities are kept in the world but hidden from its systems. WARNING: Setting this will not add or
+	// remove the entity from the relevant systems. If you want to do this, use World.SetEntityEnabled() instead.
+	Disabled bool            `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Store    *ComponentStore `json:"components" yaml:"components"`
+	// sequence records when the entity was added to its world, for OrderByCreation
+	sequence uint64
 }
 
 // NewEntity creates an entity with a unique identifier
@@ -51,15 +56,7 @@ func (e *Entity) Component(face interface{}) Component {
 // Remove a component from the entity. WARNING: This will not remove the entity/component to the relevant systems.
 // If you want to do this, use World.RemoveComponentFromEntity() instead.
 func (e *Entity) Remove(component Component) {
-	for i, c := range e.Store.components {
-		if c.Inner == component {
-			// copy whatever is at the end of the list to the position we're removing
-			e.Store.components[i] = e.Store.components[len(e.Store.components)-1]
-			// delete whatever is at the end of the list
-			e.Store.components = e.Store.components[:len(e.Store.components)-1]
-			return
-		}
-	}
+	e.Store.Remove(component)
 }
 
 func RemoveEntityFromSlice(slice []Entity, i int) []Entity {
@@ -69,6 +66,7 @@ func RemoveEntityFromSlice(slice []Entity, i int) []Entity {
 
 type ComponentStore struct {
 	components []serialisableComponent
+	ordering   Ordering
 }
 
 func (s *ComponentStore) Add(component interface{}) {
@@ -77,6 +75,22 @@ func (s *ComponentStore) Add(component interface{}) {
 	})
 }
 
+func (s *ComponentStore) Remove(component interface{}) {
+	for i, c := range s.components {
+		if c.Inner == component {
+			if s.ordering != OrderSwapRemove {
+				s.components = append(s.components[:i], s.components[i+1:]...)
+				return
+			}
+			// copy whatever is at the end of the list to the position we're removing
+			s.components[i] = s.components[len(s.components)-1]
+			// delete whatever is at the end of the list
+			s.components = s.components[:len(s.components)-1]
+			return
+		}
+	}
+}
+
 func (s *ComponentStore) List() []interface{} {
 	var list []interface{}
 	for _, c := range s.components {
@@ -90,15 +104,19 @@ type serialisableComponent struct {
 }
 
 func (s *ComponentStore) MarshalJSON() ([]byte, error) {
-	var parts []string
-	for _, comp := range s.components {
+	buf := bytes.NewBufferString("[")
+	for i, comp := range s.components {
 		data, err := comp.MarshalJSON()
 		if err != nil {
 			return nil, err
 		}
-		parts = append(parts, string(data))
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(data)
 	}
-	return []byte("[" + strings.Join(parts, ",") + "]"), nil
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
 }
 
 func (s *ComponentStore) UnmarshalJSON(data []byte) error {
@@ -106,31 +124,75 @@ func (s *ComponentStore) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &comps); err != nil {
 		return err
 	}
-	for _, c := range comps {
-		empty, err := ComponentFromName(c.Type)
-		if err != nil {
-			return err
-		}
-		if err := json.Unmarshal(c.Data, empty); err != nil {
-			return err
-		}
-		s.Add(empty)
-	}
-	return nil
+	return s.load(comps, false)
 }
 
 func (c *serialisableComponent) MarshalJSON() ([]byte, error) {
-	componentData, err := json.Marshal(c.Inner)
+	saved, err := c.saved()
 	if err != nil {
 		return nil, err
 	}
-	return json.Marshal(savedComponent{
-		Type: reflect.TypeOf(c.Inner).Elem().Name(),
+	return json.Marshal(saved)
+}
+
+func (c *serialisableComponent) saved() (savedComponent, error) {
+	componentData, codec, err := encodeComponent(c.Inner)
+	if err != nil {
+		return savedComponent{}, err
+	}
+	saved := savedComponent{
+		Type: componentName(c.Inner),
 		Data: componentData,
-	})
+	}
+	if codec {
+		saved.Encoding = encodingCodec
+		if saved.Data, err = json.Marshal(componentData); err != nil {
+			return savedComponent{}, err
+		}
+	}
+	return saved, nil
+}
+
+// componentName returns the name a component is registered and saved under.
+func componentName(component interface{}) string {
+	return typeName(reflect.TypeOf(component).Elem())
 }
 
 type savedComponent struct {
-	Type string          `json:"type"`
-	Data json.RawMessage `json:"data"`
+	Type string `json:"type"`
+	// Encoding is empty for JSON data, or encodingCodec if the component was saved by a codec.
+	Encoding string          `json:"encoding,omitempty"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// load creates a component of the saved type using the registry, and populates it with the saved data.
+func (c savedComponent) load(registry *Registry) (interface{}, error) {
+	raw, err := c.raw()
+	if err != nil {
+		return nil, err
+	}
+	return raw.load(registry, false)
+}
+
+func (c savedComponent) decodeInto(component interface{}) error {
+	raw, err := c.raw()
+	if err != nil {
+		return err
+	}
+	return raw.decodeInto(component, false)
+}
+
+// raw returns the component's data as it was produced by encodeComponent.
+func (c savedComponent) raw() (rawComponent, error) {
+	switch c.Encoding {
+	case "":
+		return rawComponent{name: c.Type, data: c.Data}, nil
+	case encodingCodec:
+		var data []byte
+		if err := json.Unmarshal(c.Data, &data); err != nil {
+			return rawComponent{}, err
+		}
+		return rawComponent{name: c.Type, data: data, codec: true}, nil
+	}
+	return rawComponent{}, fmt.Errorf("component '%s' has unknown encoding '%s'", c.Type, c.Encoding)
 }
@@ -113,7 +113,7 @@ func BenchmarkAddingComponentToEntity(b *testing.B) {
 			X: 12345,
 		}
 		b.StartTimer()
-		entity.Add(component)
+		entity.Add(&component)
 	}
 }
 
@@ -147,7 +147,7 @@ func BenchmarkRetrievingComponentFromEntityWith10OtherComponents(b *testing.B) {
 		for i := 0; i < 10; i++ {
 			entity.Add(makeComponent())
 		}
-		entity.Add(component)
+		entity.Add(&component)
 		b.StartTimer()
 		_ = entity.Component(testable)
 	}
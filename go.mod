@@ -5,4 +5,5 @@ go 1.15
 require (
 	github.com/google/uuid v1.1.2
 	github.com/stretchr/testify v1.6.1
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
@@ -0,0 +1,62 @@
+package ecs
+
+import (
+	"encoding/binary"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator creates identifiers for entities created with World.NewEntity.
+type IDGenerator interface {
+	NewID() uuid.UUID
+}
+
+// SequentialIDGenerator creates deterministic (but random looking) version 4 UUIDs from a seed and a counter. Two
+// generators with the same seed produce the same sequence of identifiers.
+type SequentialIDGenerator struct {
+	Seed  uint64 `json:"seed"`
+	Count uint64 `json:"count"`
+}
+
+func init() {
+	registerBuiltin(&SequentialIDGenerator{})
+}
+
+func NewSequentialIDGenerator(seed uint64) *SequentialIDGenerator {
+	return &SequentialIDGenerator{
+		Seed: seed,
+	}
+}
+
+func (g *SequentialIDGenerator) NewID() uuid.UUID {
+	g.Count++
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[:8], mix64(g.Seed^mix64(g.Count)))
+	binary.BigEndian.PutUint64(id[8:], mix64(g.Seed+g.Count*0x9e3779b97f4a7c15))
+	id[6] = (id[6] & 0x0f) | 0x40 // version 4
+	id[8] = (id[8] & 0x3f) | 0x80 // variant is 10
+	return id
+}
+
+// SetIDGenerator sets the generator used by World.NewEntity. The generator is stored as a resource, so its state is
+// saved with the world, and must be registered with RegisterComponent if the world is to be loaded again.
+func (w *World) SetIDGenerator(generator IDGenerator) {
+	var existing *IDGenerator
+	if current := w.Resource(existing); current != nil {
+		w.RemoveResource(current)
+	}
+	w.AddResource(generator)
+}
+
+// NewEntity creates an entity using the world's ID generator, or a random UUID if no generator has been set. The
+// entity is not added to the world.
+func (w *World) NewEntity() *Entity {
+	var generator *IDGenerator
+	if g := w.Resource(generator); g != nil {
+		return &Entity{
+			UUID:  g.(IDGenerator).NewID(),
+			Store: &ComponentStore{},
+		}
+	}
+	return NewEntity()
+}
@@ -0,0 +1,49 @@
+package ecs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequentialIDsAreDeterministic(t *testing.T) {
+	a := NewSequentialIDGenerator(7)
+	b := NewSequentialIDGenerator(7)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := a.NewID()
+		assert.Equal(t, id, b.NewID())
+		assert.Equal(t, 4, int(id.Version()))
+		assert.False(t, seen[id.String()])
+		seen[id.String()] = true
+	}
+
+	assert.NotEqual(t, NewSequentialIDGenerator(1).NewID(), NewSequentialIDGenerator(2).NewID())
+}
+
+func TestWorldUsesIDGeneratorForNewEntities(t *testing.T) {
+	a := NewWorld(0)
+	a.SetIDGenerator(NewSequentialIDGenerator(3))
+	b := NewWorld(0)
+	b.SetIDGenerator(NewSequentialIDGenerator(3))
+
+	assert.Equal(t, a.NewEntity().ID(), b.NewEntity().ID())
+	assert.Len(t, a.Resources(), 1)
+}
+
+func TestIDGeneratorStateIsSavedWithWorld(t *testing.T) {
+	world := NewWorld(0)
+	world.SetIDGenerator(NewSequentialIDGenerator(3))
+	world.AddEntity(world.NewEntity())
+
+	data, err := json.Marshal(world)
+	require.NoError(t, err)
+
+	loaded := NewWorld(0)
+	require.NoError(t, json.Unmarshal(data, loaded))
+
+	assert.Equal(t, world.NewEntity().ID(), loaded.NewEntity().ID())
+}
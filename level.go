@@ -0,0 +1,316 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// LevelMode controls how the entities of a level are kept whilst it is inactive.
+type LevelMode int
+
+const (
+	// LevelFrozen keeps the entities of an inactive level in the world, disabled (see SetEntityEnabled). Switching to
+	// a frozen level is fast, and its entities can still be found with GetEntity.
+	LevelFrozen LevelMode = iota
+	// LevelSerialised saves the entities of an inactive level and removes them from the world, so that they take up
+	// less memory and cannot be found at all until the level is active again.
+	LevelSerialised
+)
+
+// Levels is the resource in which a world keeps its levels, such as the floors of a dungeon, so that they are saved
+// and loaded along with the world. Every level shares the world's systems and resources, but only the entities of the
+// active level are given to systems. See World.AddLevel.
+type Levels struct {
+	Active string            `json:"active"`
+	Levels map[string]*Level `json:"levels"`
+}
+
+// Level is a set of entities which can be switched in and out of a world.
+type Level struct {
+	Mode LevelMode `json:"mode"`
+	// Interval is the number of updates between simulations of the level whilst it is inactive, or zero if it is not
+	// simulated. See World.SetLevelSimulation.
+	Interval int `json:"interval,omitempty"`
+	// Elapsed is the number of updates since the level was last simulated.
+	Elapsed int `json:"elapsed,omitempty"`
+	// Frozen are the entities of an inactive frozen level.
+	Frozen []uuid.UUID `json:"frozen,omitempty"`
+	// Sleeping are the entities of an inactive frozen level which were already disabled when it was frozen, and so
+	// stay disabled when it is next active.
+	Sleeping []uuid.UUID `json:"sleeping,omitempty"`
+	// Saved are the entities of an inactive serialised level, as written by json.Marshal.
+	Saved []json.RawMessage `json:"saved,omitempty"`
+}
+
+func init() {
+	registerBuiltin(&Levels{})
+}
+
+func (w *World) levels() *Levels {
+	for _, r := range w.Resources() {
+		if levels, ok := r.(*Levels); ok {
+			return levels
+		}
+	}
+	return nil
+}
+
+func (w *World) level(name string) (*Levels, *Level, error) {
+	levels := w.levels()
+	if levels == nil {
+		return nil, nil, fmt.Errorf("level '%s' not found: no levels have been added", name)
+	}
+	level, ok := levels.Levels[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("level '%s' not found", name)
+	}
+	return levels, level, nil
+}
+
+// AddLevel adds an empty, inactive level. The first level added becomes the active level, and holds the entities
+// already in the world.
+func (w *World) AddLevel(name string, mode LevelMode) error {
+	levels := w.levels()
+	if levels == nil {
+		levels = &Levels{
+			Active: name,
+			Levels: make(map[string]*Level),
+		}
+		w.AddResource(levels)
+	}
+	if _, ok := levels.Levels[name]; ok {
+		return fmt.Errorf("level '%s' already exists", name)
+	}
+	levels.Levels[name] = &Level{Mode: mode}
+	return nil
+}
+
+// ActiveLevel returns the name of the active level, or "" if no levels have been added.
+func (w *World) ActiveLevel() string {
+	if levels := w.levels(); levels != nil {
+		return levels.Active
+	}
+	return ""
+}
+
+// AddEntityToLevel adds an entity to a level. If the level is active, this is the same as AddEntity. Otherwise the
+// entity is frozen or serialised along with the rest of the level, and if it was in the active level, it is moved.
+func (w *World) AddEntityToLevel(name string, e *Entity) error {
+	levels, level, err := w.level(name)
+	if err != nil {
+		return err
+	}
+	if name == levels.Active {
+		w.AddEntity(e)
+		return nil
+	}
+	return w.storeLevel(level, []*Entity{e})
+}
+
+// SwitchLevel makes the named level active. The entities of the previously active level are frozen or serialised
+// according to its mode, apart from the player (see Player) and any entities given in carry, which move to the new
+// level.
+func (w *World) SwitchLevel(name string, carry ...*Entity) error {
+	levels, target, err := w.level(name)
+	if err != nil {
+		return err
+	}
+	if name == levels.Active {
+		return nil
+	}
+	// load the new level before touching the current one, so that a level which cannot be loaded leaves the world
+	// as it was
+	arriving, err := w.loadLevel(target)
+	if err != nil {
+		return fmt.Errorf("failed to load level '%s': %w", name, err)
+	}
+	moving := make(map[*Entity]bool)
+	if player := w.Player(); player != nil {
+		moving[player] = true
+	}
+	for _, e := range carry {
+		moving[e] = true
+	}
+	var leaving []*Entity
+	for _, e := range w.activeLevelEntities(levels) {
+		if !moving[e] {
+			leaving = append(leaving, e)
+		}
+	}
+	if err := w.storeLevel(levels.Levels[levels.Active], leaving); err != nil {
+		return fmt.Errorf("failed to store level '%s': %w", levels.Active, err)
+	}
+	w.restoreLevel(target, arriving)
+	levels.Active = name
+	target.Elapsed = 0
+	return nil
+}
+
+// SetLevelSimulation sets how often an inactive level is simulated. Every interval updates, the level's entities are
+// swapped into the world in place of those of the active level, and every enabled system is updated once more with
+// a nil player. SimulatingLevel can be used by systems to tell when this is happening. As each simulation moves the
+// entities of both levels out of and back into systems, intervals should be kept large. An interval of zero stops
+// the level from being simulated.
+func (w *World) SetLevelSimulation(name string, interval int) error {
+	_, level, err := w.level(name)
+	if err != nil {
+		return err
+	}
+	level.Interval = interval
+	level.Elapsed = 0
+	return nil
+}
+
+// SimulatingLevel returns the name of the inactive level being simulated, or "" if no level is being simulated.
+func (w *World) SimulatingLevel() string {
+	return w.simulating
+}
+
+// activeLevelEntities returns the entities in the world which do not belong to an inactive level, or to one of the
+// extra levels given.
+func (w *World) activeLevelEntities(levels *Levels, extra ...*Level) []*Entity {
+	inactive := make(map[uuid.UUID]bool)
+	for name, level := range levels.Levels {
+		if name != levels.Active {
+			extra = append(extra, level)
+		}
+	}
+	for _, level := range extra {
+		for _, id := range level.Frozen {
+			inactive[id] = true
+		}
+	}
+	var entities []*Entity
+	for _, e := range w.entities {
+		if !inactive[e.ID()] {
+			entities = append(entities, e)
+		}
+	}
+	return entities
+}
+
+// storeLevel freezes or serialises entities as part of an inactive level. Entities which are not yet in the world
+// are added to it if the level is frozen.
+func (w *World) storeLevel(level *Level, entities []*Entity) error {
+	if level.Mode == LevelSerialised {
+		saved := make([]json.RawMessage, 0, len(entities))
+		for _, e := range entities {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return fmt.Errorf("failed to save entity %s: %w", e.ID(), err)
+			}
+			saved = append(saved, data)
+		}
+		level.Saved = append(level.Saved, saved...)
+		for _, e := range entities {
+			if w.hasEntity(e) {
+				w.RemoveEntity(e)
+			}
+		}
+		return nil
+	}
+	for _, e := range entities {
+		level.Frozen = append(level.Frozen, e.ID())
+		if e.Disabled {
+			level.Sleeping = append(level.Sleeping, e.ID())
+		}
+		if w.hasEntity(e) {
+			w.SetEntityEnabled(e, false)
+		} else {
+			w.setDisabled(e, true)
+			w.AddEntity(e)
+		}
+	}
+	return nil
+}
+
+// loadLevel loads the entities of an inactive serialised level. Frozen levels are already loaded.
+func (w *World) loadLevel(level *Level) ([]*Entity, error) {
+	var entities []*Entity
+	for _, data := range level.Saved {
+		raw, err := readEntity(data, w.strictLoading)
+		if err != nil {
+			return nil, err
+		}
+		e, err := loadEntity(raw, w.Registry(), w.strictLoading)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, e)
+	}
+	return entities, nil
+}
+
+// restoreLevel returns the entities of an inactive level to the world, given those loaded by loadLevel.
+func (w *World) restoreLevel(level *Level, loaded []*Entity) {
+	sleeping := make(map[uuid.UUID]bool)
+	for _, id := range level.Sleeping {
+		sleeping[id] = true
+	}
+	for _, id := range level.Frozen {
+		// frozen entities may have been removed from the world whilst the level was inactive
+		if e := w.GetEntity(id); e != nil && !sleeping[id] {
+			w.SetEntityEnabled(e, true)
+		}
+	}
+	for _, e := range loaded {
+		w.AddEntity(e)
+	}
+	level.Frozen, level.Sleeping, level.Saved = nil, nil, nil
+}
+
+// simulateLevels simulates the inactive levels which are due. See SetLevelSimulation.
+func (w *World) simulateLevels() {
+	levels := w.levels()
+	if levels == nil {
+		return
+	}
+	names := make([]string, 0, len(levels.Levels))
+	for name := range levels.Levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		level := levels.Levels[name]
+		if name == levels.Active || level.Interval <= 0 {
+			continue
+		}
+		if level.Elapsed++; level.Elapsed < level.Interval {
+			continue
+		}
+		level.Elapsed = 0
+		if err := w.simulateLevel(levels, name, level); err != nil {
+			w.reportError(fmt.Errorf("failed to simulate level '%s': %w", name, err))
+		}
+	}
+}
+
+// simulateLevel swaps the entities of an inactive level in for those of the active level, updates the systems, and
+// swaps them back. It runs during an update, so in concurrent mode the swaps are deferred, and are applied here
+// before the systems are updated and before returning.
+func (w *World) simulateLevel(levels *Levels, name string, level *Level) error {
+	loaded, err := w.loadLevel(level)
+	if err != nil {
+		return err
+	}
+	// the active level is frozen for the duration of the simulation, whatever its mode
+	active := &Level{Mode: LevelFrozen}
+	if err := w.storeLevel(active, w.activeLevelEntities(levels)); err != nil {
+		return err
+	}
+	w.restoreLevel(level, loaded)
+	w.applyDeferred(false)
+
+	w.simulating = name
+	w.updateSystems()
+	w.simulating = ""
+
+	// any entities created during the simulation belong to the simulated level
+	err = w.storeLevel(level, w.activeLevelEntities(levels, active))
+	w.restoreLevel(active, nil)
+	w.applyDeferred(false)
+	return err
+}
@@ -0,0 +1,179 @@
+package ecs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// CountingSystem increments the TestComponent of each of its entities on every update.
+type CountingSystem struct {
+	TestSystem
+	levels  []string
+	players []*Entity
+}
+
+func (s *CountingSystem) Update(w *World, p *Entity) {
+	s.TestSystem.Update(w, p)
+	s.levels = append(s.levels, w.SimulatingLevel())
+	s.players = append(s.players, p)
+	for _, e := range w.SystemEntities(s) {
+		e.Component(IsTestable).(Testable).TestComponent().X++
+	}
+}
+
+func buildLevelWorld(t *testing.T, mode LevelMode) (*World, *Entity, *Entity, *Entity) {
+	world := NewWorld(0)
+	player := NewEntity()
+	player.Add(&TestComponent{})
+	world.AddEntity(player)
+	world.SetPlayer(player)
+	upstairs := NewEntity()
+	upstairs.Add(&TestComponent{})
+	world.AddEntity(upstairs)
+
+	require.NoError(t, world.AddLevel("1", LevelFrozen))
+	require.NoError(t, world.AddLevel("2", mode))
+	downstairs := NewEntity()
+	downstairs.Add(&TestComponent{})
+	require.NoError(t, world.AddEntityToLevel("2", downstairs))
+	return world, player, upstairs, downstairs
+}
+
+func TestSwitchingLevelsMovesThePlayer(t *testing.T) {
+	for _, mode := range []LevelMode{LevelFrozen, LevelSerialised} {
+		world, player, upstairs, downstairs := buildLevelWorld(t, mode)
+		system := &TestSystem{}
+		world.AddSystem(system, false)
+		assert.Equal(t, "1", world.ActiveLevel())
+		assert.Equal(t, []*Entity{player, upstairs}, world.GetEntities())
+
+		require.NoError(t, world.SwitchLevel("2"))
+		assert.Equal(t, "2", world.ActiveLevel())
+		assert.Equal(t, []*Entity{upstairs}, system.removedEntities)
+		require.Len(t, world.GetEntities(), 2)
+		assert.Equal(t, player, world.Player())
+		assert.Equal(t, downstairs.ID(), world.GetEntities()[1].ID())
+		// the first level is frozen, so its entities can still be found
+		assert.Equal(t, upstairs, world.GetEntity(upstairs.ID()))
+
+		require.NoError(t, world.SwitchLevel("1"))
+		assert.Equal(t, []*Entity{player, upstairs}, world.GetEntities())
+		if mode == LevelSerialised {
+			assert.Nil(t, world.GetEntity(downstairs.ID()))
+		}
+	}
+}
+
+func TestEntitiesCanBeCarriedBetweenLevels(t *testing.T) {
+	world, player, upstairs, _ := buildLevelWorld(t, LevelSerialised)
+	pet := NewEntity()
+	world.AddEntity(pet)
+
+	require.NoError(t, world.SwitchLevel("2", pet))
+	assert.Contains(t, world.GetEntities(), player)
+	assert.Contains(t, world.GetEntities(), pet)
+	assert.NotContains(t, world.GetEntities(), upstairs)
+}
+
+func TestDisabledEntitiesStayDisabledWhenTheirLevelIsActivatedAgain(t *testing.T) {
+	world, _, upstairs, _ := buildLevelWorld(t, LevelFrozen)
+	world.SetEntityEnabled(upstairs, false)
+
+	require.NoError(t, world.SwitchLevel("2"))
+	require.NoError(t, world.SwitchLevel("1"))
+	assert.True(t, upstairs.Disabled)
+}
+
+func TestLevelsAreSavedWithTheWorld(t *testing.T) {
+	for _, options := range saveOptions {
+		world, player, upstairs, downstairs := buildLevelWorld(t, LevelSerialised)
+		require.NoError(t, world.SwitchLevel("2"))
+		require.NoError(t, world.SwitchLevel("1"))
+
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, world.Save(buf, options))
+		loaded := NewWorld(0)
+		system := &TestSystem{}
+		loaded.AddSystem(system, false)
+		require.NoError(t, loaded.Load(buf))
+		assert.Len(t, system.addedEntities, 2)
+
+		require.NoError(t, loaded.SwitchLevel("2"))
+		ids := []interface{}{}
+		for _, e := range loaded.GetEntities() {
+			ids = append(ids, e.ID())
+		}
+		assert.ElementsMatch(t, ids, []interface{}{player.ID(), downstairs.ID()})
+		assert.NotNil(t, loaded.GetEntity(upstairs.ID()))
+	}
+}
+
+func TestLevelsAreSavedUnderTheLibraryName(t *testing.T) {
+	world, _, _, _ := buildLevelWorld(t, LevelSerialised)
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, world.Save(buf, SaveOptions{}))
+
+	doc, err := ReadDocument(buf)
+	require.NoError(t, err)
+	var types []string
+	for _, r := range doc.Resources {
+		types = append(types, r.Type)
+	}
+	assert.Contains(t, types, "ecs.Levels")
+}
+
+func TestInactiveLevelsCanBeSimulatedAtAReducedRate(t *testing.T) {
+	for _, mode := range []LevelMode{LevelFrozen, LevelSerialised} {
+		world, player, upstairs, downstairs := buildLevelWorld(t, mode)
+		system := &CountingSystem{}
+		world.AddSystem(system, false)
+		require.NoError(t, world.SetLevelSimulation("2", 3))
+
+		for i := 0; i < 6; i++ {
+			require.NoError(t, world.TryUpdate())
+		}
+		assert.Equal(t, 6, upstairs.Component(IsTestable).(Testable).TestComponent().X)
+		assert.Equal(t, 6, player.Component(IsTestable).(Testable).TestComponent().X)
+		assert.Equal(t, []string{"", "", "", "2", "", "", "", "2"}, system.levels)
+		assert.Nil(t, system.players[3])
+
+		require.NoError(t, world.SwitchLevel("2"))
+		assert.Equal(t, 2, world.GetEntity(downstairs.ID()).Component(IsTestable).(Testable).TestComponent().X)
+		assert.Equal(t, []*Entity{player, world.GetEntity(downstairs.ID())}, world.SystemEntities(system))
+	}
+}
+
+func TestInactiveLevelsCanBeSimulatedInConcurrentMode(t *testing.T) {
+	for _, mode := range []LevelMode{LevelFrozen, LevelSerialised} {
+		world, player, upstairs, downstairs := buildLevelWorld(t, mode)
+		world.SetConcurrent(true)
+		system := &CountingSystem{}
+		world.AddSystem(system, false)
+		require.NoError(t, world.SetLevelSimulation("2", 3))
+
+		for i := 0; i < 6; i++ {
+			require.NoError(t, world.TryUpdate())
+		}
+		assert.Equal(t, 6, upstairs.Component(IsTestable).(Testable).TestComponent().X)
+		assert.Equal(t, []string{"", "", "", "2", "", "", "", "2"}, system.levels)
+		assert.Equal(t, []*Entity{player, upstairs}, world.GetEntities())
+		assert.Equal(t, []*Entity{player, upstairs}, world.SystemEntities(system))
+
+		require.NoError(t, world.SwitchLevel("2"))
+		assert.Equal(t, 2, world.GetEntity(downstairs.ID()).Component(IsTestable).(Testable).TestComponent().X)
+	}
+}
+
+func TestUnknownLevelsAreRejected(t *testing.T) {
+	world := NewWorld(0)
+	assert.Error(t, world.SwitchLevel("1"))
+	require.NoError(t, world.AddLevel("1", LevelFrozen))
+	assert.Error(t, world.AddLevel("1", LevelFrozen))
+	assert.Error(t, world.SwitchLevel("2"))
+	assert.Error(t, world.AddEntityToLevel("2", NewEntity()))
+	assert.Error(t, world.SetLevelSimulation("2", 1))
+	assert.NoError(t, world.SwitchLevel("1"))
+}
@@ -0,0 +1,316 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Validator can be implemented by components to check their invariants once they have been loaded strictly (see
+// World.SetStrictLoading and LoadEntityStrict). If Validate returns an error, the load fails with a *LoadError.
+type Validator interface {
+	Validate() error
+}
+
+// LoadError describes a component which could not be loaded.
+type LoadError struct {
+	// Entity is the UUID of the entity holding the component. It is uuid.Nil for world resources, and for components
+	// loaded by ComponentStore.UnmarshalJSON outside of an entity.
+	Entity uuid.UUID
+	// Index is the position of the component within its entity.
+	Index int
+	Type  string
+	Err   error
+}
+
+func (e *LoadError) Error() string {
+	if e.Entity == uuid.Nil {
+		return fmt.Sprintf("component %d (%s): %s", e.Index, e.Type, e.Err)
+	}
+	return fmt.Sprintf("entity %s: component %d (%s): %s", e.Entity, e.Index, e.Type, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+var errDuplicateComponent = errors.New("entity already has a component of this type")
+
+// SetStrictLoading enables strict loading for World.Load, World.UnmarshalJSON and World.UnmarshalYAML. Strict loads
+// reject unknown fields, both in the save itself and in component data, and entities holding more than one component
+// of a type which was not registered with RegisterRepeatableComponent, and run the Validate method of components which
+// implement Validator. Errors in components are returned as a *LoadError.
+func (w *World) SetStrictLoading(strict bool) {
+	w.strictLoading = strict
+}
+
+// LoadEntityStrict reads an entity written by Entity.Save, in any format, rejecting it in the same way as a strict
+// world load. See World.SetStrictLoading.
+func LoadEntityStrict(in io.Reader) (*Entity, error) {
+	return DefaultRegistry.LoadEntityStrict(in)
+}
+
+// LoadEntityStrict reads an entity as the package function of the same name does, creating its components from the
+// registry.
+func (r *Registry) LoadEntityStrict(in io.Reader) (*Entity, error) {
+	return loadEntityFrom(in, r, true)
+}
+
+func loadEntityFrom(in io.Reader, registry *Registry, strict bool) (*Entity, error) {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = decompress(data); err != nil {
+		return nil, err
+	}
+	raw, err := readEntity(data, strict)
+	if err != nil {
+		return nil, err
+	}
+	return loadEntity(raw, registry, strict)
+}
+
+// load creates the component from the registry and decodes it, running its Validate method if it has one and the load
+// is strict.
+func (c rawComponent) load(registry *Registry, strict bool) (interface{}, error) {
+	component, err := registry.ComponentFromName(c.name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.decodeInto(component, strict); err != nil {
+		return nil, err
+	}
+	return component, nil
+}
+
+func (c rawComponent) decodeInto(component interface{}, strict bool) error {
+	if strict && !c.codec {
+		dec := json.NewDecoder(bytes.NewReader(c.data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(component); err != nil {
+			return err
+		}
+	} else if err := decodeComponent(component, c.data, c.codec); err != nil {
+		return err
+	}
+	if v, ok := component.(Validator); ok && strict {
+		return v.Validate()
+	}
+	return nil
+}
+
+func (s *ComponentStore) load(comps []savedComponent, strict bool) error {
+	raw := make([]rawComponent, 0, len(comps))
+	for i, c := range comps {
+		r, err := c.raw()
+		if err != nil {
+			return &LoadError{Index: i, Type: c.Type, Err: err}
+		}
+		raw = append(raw, r)
+	}
+	return s.loadRaw(raw, DefaultRegistry, strict)
+}
+
+func (s *ComponentStore) loadRaw(raw []rawComponent, registry *Registry, strict bool) error {
+	seen := make(map[reflect.Type]bool)
+	for i, c := range raw {
+		component, err := c.load(registry, strict)
+		if err != nil {
+			return &LoadError{Index: i, Type: c.name, Err: err}
+		}
+		t := reflect.TypeOf(component).Elem()
+		if strict && seen[t] && !registry.repeatable[t] {
+			return &LoadError{Index: i, Type: c.name, Err: errDuplicateComponent}
+		}
+		seen[t] = true
+		s.Add(component)
+	}
+	return nil
+}
+
+func loadEntity(raw rawEntity, registry *Registry, strict bool) (*Entity, error) {
+	e := &Entity{
+		UUID:     raw.id,
+		Disabled: raw.disabled,
+		Store:    &ComponentStore{},
+	}
+	if err := e.Store.loadRaw(raw.components, registry, strict); err != nil {
+		var loadErr *LoadError
+		if errors.As(err, &loadErr) {
+			loadErr.Entity = raw.id
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+func loadWorld(f *frozenWorld, registry *Registry, strict bool) (*savedWorld, error) {
+	saved := &savedWorld{
+		Turn:        f.frozenTurn,
+		Controllers: f.frozenControllers,
+		Resources:   &ComponentStore{},
+	}
+	if err := saved.Resources.loadRaw(f.frozenResources, registry, strict); err != nil {
+		return nil, fmt.Errorf("resources: %w", err)
+	}
+	for _, raw := range f.entities {
+		e, err := loadEntity(raw, registry, strict)
+		if err != nil {
+			return nil, err
+		}
+		saved.Entities = append(saved.Entities, e)
+	}
+	return saved, nil
+}
+
+// loadSave replaces the state of the world with a save in any format, once its signature has been checked.
+func (w *World) loadSave(data []byte) error {
+	data, err := decompress(data)
+	if err != nil {
+		return err
+	}
+	var f *frozenWorld
+	switch {
+	case isBinary(data):
+		f, err = readBinaryWorld(data)
+	case isYAML(data):
+		var doc *savedDocument
+		if doc, err = readYAMLDocument(data, w.strictLoading); err == nil {
+			f, err = doc.frozen()
+		}
+	default:
+		var doc *savedDocument
+		if doc, err = readJSONDocument(data, w.strictLoading); err == nil {
+			f, err = doc.frozen()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return w.loadFrozen(f)
+}
+
+func (w *World) loadDocument(doc *savedDocument) error {
+	f, err := doc.frozen()
+	if err != nil {
+		return err
+	}
+	return w.loadFrozen(f)
+}
+
+func (w *World) loadFrozen(f *frozenWorld) error {
+	saved, err := loadWorld(f, w.Registry(), w.strictLoading)
+	if err != nil {
+		return err
+	}
+	w.load(saved)
+	return nil
+}
+
+// savedEntity is an entity as it appears in a JSON or YAML save, before its components are loaded.
+type savedEntity struct {
+	UUID       uuid.UUID        `json:"uuid" yaml:"uuid"`
+	Disabled   bool             `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Components []savedComponent `json:"components" yaml:"components"`
+}
+
+func (e savedEntity) raw() (rawEntity, error) {
+	raw := rawEntity{
+		id:         e.UUID,
+		disabled:   e.Disabled,
+		components: make([]rawComponent, 0, len(e.Components)),
+	}
+	for i, c := range e.Components {
+		r, err := c.raw()
+		if err != nil {
+			return raw, &LoadError{Entity: e.UUID, Index: i, Type: c.Type, Err: err}
+		}
+		raw.components = append(raw.components, r)
+	}
+	return raw, nil
+}
+
+// savedDocument is a world as it appears in a JSON or YAML save, before its components are loaded.
+type savedDocument struct {
+	Turn        int64             `json:"turn" yaml:"turn"`
+	Entities    []savedEntity     `json:"entities" yaml:"entities"`
+	Controllers []savedController `json:"controllers,omitempty" yaml:"controllers,omitempty"`
+	Resources   []savedComponent  `json:"resources,omitempty" yaml:"resources,omitempty"`
+}
+
+func (d *savedDocument) frozen() (*frozenWorld, error) {
+	f := &frozenWorld{
+		frozenTurn:        d.Turn,
+		frozenControllers: d.Controllers,
+		entities:          make([]rawEntity, 0, len(d.Entities)),
+	}
+	resources, err := savedEntity{Components: d.Resources}.raw()
+	if err != nil {
+		return nil, fmt.Errorf("resources: %w", err)
+	}
+	f.frozenResources = resources.components
+	for _, e := range d.Entities {
+		raw, err := e.raw()
+		if err != nil {
+			return nil, err
+		}
+		f.entities = append(f.entities, raw)
+	}
+	return f, nil
+}
+
+func readJSONDocument(data []byte, strict bool) (*savedDocument, error) {
+	var doc savedDocument
+	if err := decodeJSON(data, &doc, strict); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func readYAMLDocument(data []byte, strict bool) (*savedDocument, error) {
+	var doc savedDocument
+	if err := decodeYAML(data, &doc, strict); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// readEntity reads an entity written by Entity.Save in any format, once it has been decompressed.
+func readEntity(data []byte, strict bool) (rawEntity, error) {
+	if isBinary(data) {
+		return readBinaryEntity(data)
+	}
+	var e savedEntity
+	var err error
+	if isYAML(data) {
+		err = decodeYAML(data, &e, strict)
+	} else {
+		err = decodeJSON(data, &e, strict)
+	}
+	if err != nil {
+		return rawEntity{}, err
+	}
+	return e.raw()
+}
+
+func decodeJSON(data []byte, v interface{}, strict bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(v)
+}
+
+func decodeYAML(data []byte, v interface{}, strict bool) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(strict)
+	return dec.Decode(v)
+}
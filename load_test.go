@@ -0,0 +1,147 @@
+package ecs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type HealthComponent struct {
+	HP int `json:"hp"`
+}
+
+func (c *HealthComponent) Validate() error {
+	if c.HP < 0 {
+		return fmt.Errorf("hp must not be negative, got %d", c.HP)
+	}
+	return nil
+}
+
+type TagComponent struct {
+	Tag string `json:"tag"`
+}
+
+func init() {
+	RegisterComponent(&HealthComponent{})
+	RegisterRepeatableComponent(&TagComponent{})
+}
+
+var loadTestID = uuid.MustParse("7d4a0c38-8b0c-4d42-9a9c-3f7b2b1f9e01")
+
+func worldJSON(components ...string) string {
+	return `{"turn":3,"entities":[{"uuid":"` + loadTestID.String() + `","components":[` + strings.Join(components, ",") + `]}]}`
+}
+
+func strictWorld() *World {
+	world := NewWorld(0)
+	world.SetStrictLoading(true)
+	return world
+}
+
+func requireLoadError(t *testing.T, err error, index int, typ string) *LoadError {
+	var loadErr *LoadError
+	require.True(t, errors.As(err, &loadErr), "expected a *LoadError, got %v", err)
+	assert.Equal(t, loadTestID, loadErr.Entity)
+	assert.Equal(t, index, loadErr.Index)
+	assert.Equal(t, typ, loadErr.Type)
+	return loadErr
+}
+
+func TestStrictLoadingRejectsUnknownComponentFields(t *testing.T) {
+	data := worldJSON(`{"type":"HealthComponent","data":{"hp":3}}`, `{"type":"TestComponent","data":{"X":1,"Y":2}}`)
+
+	require.NoError(t, NewWorld(0).Load(strings.NewReader(data)))
+
+	err := strictWorld().Load(strings.NewReader(data))
+	requireLoadError(t, err, 1, "TestComponent")
+	assert.Contains(t, err.Error(), loadTestID.String())
+}
+
+func TestStrictLoadingRejectsUnknownDocumentFields(t *testing.T) {
+	data := `{"turn":3,"entities":[],"weather":"rain"}`
+
+	require.NoError(t, NewWorld(0).Load(strings.NewReader(data)))
+	assert.Error(t, strictWorld().Load(strings.NewReader(data)))
+}
+
+func TestStrictLoadingRejectsDuplicateComponents(t *testing.T) {
+	data := worldJSON(`{"type":"HealthComponent","data":{"hp":3}}`, `{"type":"HealthComponent","data":{"hp":4}}`)
+
+	require.NoError(t, NewWorld(0).Load(strings.NewReader(data)))
+
+	err := strictWorld().Load(strings.NewReader(data))
+	loadErr := requireLoadError(t, err, 1, "HealthComponent")
+	assert.Equal(t, errDuplicateComponent, loadErr.Err)
+}
+
+func TestStrictLoadingAllowsRepeatableComponents(t *testing.T) {
+	data := worldJSON(`{"type":"TagComponent","data":{"tag":"a"}}`, `{"type":"TagComponent","data":{"tag":"b"}}`)
+
+	world := strictWorld()
+	require.NoError(t, world.Load(strings.NewReader(data)))
+	assert.Len(t, world.GetEntity(loadTestID).Store.List(), 2)
+}
+
+func TestUnregisteredComponentsIncludeEntityInError(t *testing.T) {
+	data := worldJSON(`{"type":"HealthComponent","data":{"hp":3}}`, `{"type":"MissingComponent","data":{}}`)
+
+	requireLoadError(t, NewWorld(0).Load(strings.NewReader(data)), 1, "MissingComponent")
+}
+
+func TestComponentsAreValidatedAfterStrictLoading(t *testing.T) {
+	data := worldJSON(`{"type":"HealthComponent","data":{"hp":-1}}`)
+
+	err := strictWorld().Load(strings.NewReader(data))
+	requireLoadError(t, err, 0, "HealthComponent")
+	assert.Contains(t, err.Error(), "hp must not be negative")
+
+	assert.NoError(t, NewWorld(0).Load(strings.NewReader(data)))
+}
+
+func TestStrictLoadingAppliesToUnmarshalJSON(t *testing.T) {
+	data := worldJSON(`{"type":"HealthComponent","data":{"hp":3,"mp":1}}`)
+
+	requireLoadError(t, strictWorld().UnmarshalJSON([]byte(data)), 0, "HealthComponent")
+}
+
+func TestLoadEntityStrict(t *testing.T) {
+	for _, options := range saveOptions {
+		t.Run(fmt.Sprintf("%d-%t", options.Format, options.Compress), func(t *testing.T) {
+			e := &Entity{UUID: loadTestID, Store: &ComponentStore{}}
+			e.Add(&HealthComponent{HP: 1})
+			e.Add(&HealthComponent{HP: 2})
+
+			buf := bytes.NewBuffer(nil)
+			require.NoError(t, e.Save(buf, options))
+			data := buf.Bytes()
+
+			_, err := LoadEntity(bytes.NewReader(data))
+			require.NoError(t, err)
+
+			_, err = LoadEntityStrict(bytes.NewReader(data))
+			requireLoadError(t, err, 1, "HealthComponent")
+		})
+	}
+}
+
+func TestStrictYAMLLoadingRejectsUnknownFields(t *testing.T) {
+	data := `
+turn: 1
+entities:
+  - uuid: ` + loadTestID.String() + `
+    components:
+      - HealthComponent:
+          hp: 3
+          armour: 2
+`
+	require.NoError(t, NewWorld(0).Load(strings.NewReader(data)))
+	requireLoadError(t, strictWorld().Load(strings.NewReader(data)), 0, "HealthComponent")
+
+	assert.Error(t, strictWorld().Load(strings.NewReader("turn: 1\nentities: []\nweather: rain\n")))
+}
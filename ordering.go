@@ -0,0 +1,93 @@
+package ecs
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Ordering controls the order of entities returned by World.GetEntities, of entities given to systems, and of
+// components returned by ComponentStore.List.
+type Ordering int
+
+const (
+	// OrderSwapRemove is the fastest ordering. Items are kept in insertion order until one is removed, at which point
+	// the last item is moved into its place. The order therefore depends on removal history. This is the default.
+	OrderSwapRemove Ordering = iota
+	// OrderByCreation keeps entities in the order they were added to the world, regardless of removals or of when
+	// they were given to each system. Components are kept in the order they were added.
+	OrderByCreation
+	// OrderByID keeps entities sorted by UUID. Components are kept in the order they were added.
+	OrderByID
+)
+
+// SetOrdering sets the ordering guarantee for the world, sorting the existing entities if OrderByCreation or
+// OrderByID is chosen. The order of components which have already been lost by OrderSwapRemove cannot be recovered, so
+// the ordering should be set before components are removed.
+func (w *World) SetOrdering(ordering Ordering) {
+	w.ordering = ordering
+	w.resourceStore().ordering = ordering
+	for _, e := range w.entities {
+		e.Store.ordering = ordering
+	}
+	if ordering != OrderSwapRemove {
+		w.concurrency.entities.Lock()
+		defer w.concurrency.entities.Unlock()
+		sortEntities(w.entities, ordering)
+		for _, reg := range w.registrations {
+			sortEntities(reg.members, ordering)
+		}
+	}
+}
+
+// SystemEntities returns the entities the system has been given via System.Add and not since removed, in the
+// order guaranteed by the world's Ordering. The entities are copied, so that the list does not change as the world
+// does.
+func (w *World) SystemEntities(system System) []*Entity {
+	reg := w.registration(system)
+	if reg == nil {
+		return nil
+	}
+	return w.snapshot(&reg.members)
+}
+
+func sortEntities(entities []*Entity, ordering Ordering) {
+	sort.SliceStable(entities, func(i, j int) bool {
+		return entityLess(entities[i], entities[j], ordering)
+	})
+}
+
+func entityLess(a, b *Entity, ordering Ordering) bool {
+	if ordering == OrderByCreation {
+		return a.sequence < b.sequence
+	}
+	return bytes.Compare(a.UUID[:], b.UUID[:]) < 0
+}
+
+func insertEntity(entities []*Entity, e *Entity, ordering Ordering) []*Entity {
+	if ordering == OrderSwapRemove {
+		return append(entities, e)
+	}
+	i := sort.Search(len(entities), func(i int) bool {
+		return entityLess(e, entities[i], ordering)
+	})
+	entities = append(entities, nil)
+	copy(entities[i+1:], entities[i:])
+	entities[i] = e
+	return entities
+}
+
+func removeEntity(entities []*Entity, e *Entity, ordering Ordering) []*Entity {
+	for i, existing := range entities {
+		if existing != e {
+			continue
+		}
+		if ordering == OrderSwapRemove {
+			entities[i] = entities[len(entities)-1]
+			return entities[:len(entities)-1]
+		}
+		copy(entities[i:], entities[i+1:])
+		entities[len(entities)-1] = nil
+		return entities[:len(entities)-1]
+	}
+	return entities
+}
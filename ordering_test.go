@@ -0,0 +1,186 @@
+package ecs
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwapRemoveOrderingMovesLastEntity(t *testing.T) {
+	world := NewWorld(0)
+	a, b, c := NewEntity(), NewEntity(), NewEntity()
+	world.AddEntity(a)
+	world.AddEntity(b)
+	world.AddEntity(c)
+
+	world.RemoveEntity(a)
+
+	assert.Equal(t, []*Entity{c, b}, world.GetEntities())
+}
+
+func TestCreationOrderingIsStable(t *testing.T) {
+	world := NewWorld(0)
+	world.SetOrdering(OrderByCreation)
+
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+
+	var entities []*Entity
+	for i := 0; i < 5; i++ {
+		e := NewEntity()
+		e.Add(&TestComponent{X: i})
+		world.AddEntity(e)
+		entities = append(entities, e)
+	}
+
+	world.RemoveEntity(entities[1])
+	world.RemoveEntity(entities[3])
+
+	expected := []*Entity{entities[0], entities[2], entities[4]}
+	assert.Equal(t, expected, world.GetEntities())
+	assert.Equal(t, expected, world.SystemEntities(system))
+}
+
+func TestCreationOrderingAppliesToSystemsJoinedLater(t *testing.T) {
+	world := NewWorld(0)
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+
+	a, b, c := NewEntity(), NewEntity(), NewEntity()
+	world.AddEntity(a)
+	world.AddEntity(b)
+	world.AddEntity(c)
+	world.AddComponentToEntity(&TestComponent{}, c)
+	world.AddComponentToEntity(&TestComponent{}, a)
+	world.SetOrdering(OrderByCreation)
+	world.AddComponentToEntity(&TestComponent{}, b)
+
+	assert.Equal(t, []*Entity{a, b, c}, world.SystemEntities(system))
+	world.RemoveEntity(a)
+	world.AddEntity(a)
+	assert.Equal(t, []*Entity{b, c, a}, world.GetEntities())
+}
+
+func TestEntityListsAreCopies(t *testing.T) {
+	world := NewWorld(0)
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+	e := NewEntity()
+	e.Add(&TestComponent{})
+	world.AddEntity(e)
+
+	world.GetEntities()[0] = nil
+	world.SystemEntities(system)[0] = nil
+	assert.Equal(t, []*Entity{e}, world.GetEntities())
+	assert.Equal(t, []*Entity{e}, world.SystemEntities(system))
+}
+
+func TestIDOrderingSortsEntities(t *testing.T) {
+	world := NewWorld(0)
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+
+	for i := 0; i < 10; i++ {
+		e := NewEntity()
+		e.Add(&TestComponent{})
+		world.AddEntity(e)
+	}
+
+	world.SetOrdering(OrderByID)
+
+	for i := 0; i < 10; i++ {
+		e := NewEntity()
+		e.Add(&TestComponent{})
+		world.AddEntity(e)
+	}
+	world.RemoveEntity(world.GetEntities()[4])
+
+	for _, entities := range [][]*Entity{world.GetEntities(), world.SystemEntities(system)} {
+		require.Len(t, entities, 19)
+		for i := 1; i < len(entities); i++ {
+			assert.True(t, bytes.Compare(entities[i-1].UUID[:], entities[i].UUID[:]) < 0)
+		}
+	}
+}
+
+func TestStableOrderingKeepsComponentOrder(t *testing.T) {
+	world := NewWorld(0)
+	world.SetOrdering(OrderByCreation)
+
+	a, b, c := &TestComponent{X: 1}, &TestComponent{X: 2}, &TestComponent{X: 3}
+	e := NewEntity()
+	e.Add(a)
+	e.Add(b)
+	e.Add(c)
+	world.AddEntity(e)
+
+	world.RemoveComponentFromEntity(a, e)
+
+	assert.Equal(t, []interface{}{b, c}, e.Store.List())
+}
+
+func benchmarkRemovingEntities(b *testing.B, ordering Ordering) {
+	for _, count := range []int{100, 10000} {
+		b.Run(fmt.Sprintf("%d", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				world := NewWorld(0)
+				world.SetOrdering(ordering)
+				for j := 0; j < count; j++ {
+					world.AddEntity(NewEntity())
+				}
+				victims := make([]*Entity, 0, count/10)
+				for j := 0; j < count; j += 10 {
+					victims = append(victims, world.GetEntities()[j])
+				}
+				b.StartTimer()
+				for _, e := range victims {
+					world.RemoveEntity(e)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRemovingEntitiesWithSwapRemoveOrdering(b *testing.B) {
+	benchmarkRemovingEntities(b, OrderSwapRemove)
+}
+
+func BenchmarkRemovingEntitiesWithCreationOrdering(b *testing.B) {
+	benchmarkRemovingEntities(b, OrderByCreation)
+}
+
+func BenchmarkRemovingEntitiesWithIDOrdering(b *testing.B) {
+	benchmarkRemovingEntities(b, OrderByID)
+}
+
+func benchmarkAddingEntities(b *testing.B, ordering Ordering) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		world := NewWorld(0)
+		world.SetOrdering(ordering)
+		entities := make([]*Entity, 1000)
+		for j := range entities {
+			entities[j] = NewEntity()
+		}
+		b.StartTimer()
+		for _, e := range entities {
+			world.AddEntity(e)
+		}
+	}
+}
+
+func BenchmarkAddingEntitiesWithSwapRemoveOrdering(b *testing.B) {
+	benchmarkAddingEntities(b, OrderSwapRemove)
+}
+
+func BenchmarkAddingEntitiesWithCreationOrdering(b *testing.B) {
+	benchmarkAddingEntities(b, OrderByCreation)
+}
+
+func BenchmarkAddingEntitiesWithIDOrdering(b *testing.B) {
+	benchmarkAddingEntities(b, OrderByID)
+}
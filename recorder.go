@@ -0,0 +1,136 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Recorder writes the state of a world followed by every command applied to it, so that the session can later be
+// reproduced with Replay. The recording is a stream of JSON lines: the initial world save, then one line per update
+// in which commands were applied, then an end marker written by Close.
+type Recorder struct {
+	world *World
+	enc   *json.Encoder
+	frame uint64
+	err   error
+}
+
+type recordedFrame struct {
+	World    json.RawMessage  `json:"world,omitempty"`
+	Frame    uint64           `json:"frame"`
+	Turn     int64            `json:"turn"`
+	Commands []savedComponent `json:"commands,omitempty"`
+	End      bool             `json:"end,omitempty"`
+}
+
+// NewRecorder writes the current state of the world to out, and records each subsequent update of the world until
+// the recorder is closed. Only one recorder can be attached to a world at a time.
+func NewRecorder(out io.Writer, w *World) (*Recorder, error) {
+	data, err := json.Marshal(w)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		world: w,
+		enc:   json.NewEncoder(out),
+	}
+	if err := r.enc.Encode(recordedFrame{World: data, Turn: w.turn}); err != nil {
+		return nil, err
+	}
+	w.recorder = r
+	return r, nil
+}
+
+// record is called once per update with the turn at the start of the update and the commands which were applied.
+func (r *Recorder) record(turn int64, commands []Command) {
+	defer func() { r.frame++ }()
+	if r.err != nil || len(commands) == 0 {
+		return
+	}
+	frame := recordedFrame{
+		Frame: r.frame,
+		Turn:  turn,
+	}
+	for _, command := range commands {
+		saved, err := marshalCommand(command)
+		if err != nil {
+			r.err = err
+			return
+		}
+		frame.Commands = append(frame.Commands, saved)
+	}
+	r.err = r.enc.Encode(frame)
+}
+
+// Close stops recording and writes the end marker. It returns the first error encountered whilst recording.
+func (r *Recorder) Close() error {
+	if r.world.recorder == r {
+		r.world.recorder = nil
+	}
+	if r.err != nil {
+		return r.err
+	}
+	return r.enc.Encode(recordedFrame{
+		Frame: r.frame,
+		Turn:  r.world.turn,
+		End:   true,
+	})
+}
+
+// Replay loads the world from a recording and reruns every recorded update, queueing the recorded commands at the
+// same points they were originally applied. The world should already have its (headless) systems added. An error is
+// returned if the world's turn diverges from the recording. If the recording ends without the end marker written by
+// Close, as it does when the game crashed whilst recording, the last recorded update is still replayed, and
+// io.ErrUnexpectedEOF is returned as any updates after it are unknown.
+func Replay(in io.Reader, w *World) error {
+	dec := json.NewDecoder(in)
+
+	var header recordedFrame
+	if err := dec.Decode(&header); err != nil {
+		return err
+	}
+	if header.World == nil {
+		return fmt.Errorf("recording does not start with a world")
+	}
+	if err := json.Unmarshal(header.World, w); err != nil {
+		return err
+	}
+
+	var frame uint64
+	// pending is true whilst the commands of the last frame read are queued but not yet applied
+	var pending bool
+	for {
+		var next recordedFrame
+		if err := dec.Decode(&next); err == io.EOF {
+			if pending {
+				if err := w.TryUpdate(); err != nil {
+					return err
+				}
+			}
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		pending = false
+		for ; frame < next.Frame; frame++ {
+			if err := w.TryUpdate(); err != nil {
+				return err
+			}
+		}
+		if next.Turn != w.turn {
+			return fmt.Errorf("replay diverged at frame %d: expected turn %d, got %d", frame, next.Turn, w.turn)
+		}
+		if next.End {
+			return nil
+		}
+		for _, saved := range next.Commands {
+			command, err := unmarshalCommand(saved)
+			if err != nil {
+				return err
+			}
+			w.QueueCommand(command)
+			pending = true
+		}
+	}
+}
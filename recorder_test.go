@@ -0,0 +1,102 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordedSessionCanBeReplayed(t *testing.T) {
+	world := NewWorld(0)
+	world.AddSystem(&ComponentReadingSystem{}, false)
+
+	e := NewEntity()
+	e.Add(&TestComponent{X: 1})
+	world.AddEntity(e)
+	world.SetPlayer(e)
+
+	buf := bytes.NewBuffer(nil)
+	recorder, err := NewRecorder(buf, world)
+	require.NoError(t, err)
+
+	require.NoError(t, world.TryUpdate())
+	world.QueueCommand(&MoveCommand{Entity: e.ID(), DX: 2})
+	require.NoError(t, world.TryUpdate())
+	require.NoError(t, world.TryUpdate())
+	world.QueueCommand(&MoveCommand{Entity: e.ID(), DX: -5})
+	world.QueueCommand(&MoveCommand{Entity: e.ID(), DX: 1})
+	require.NoError(t, world.TryUpdate())
+	require.NoError(t, recorder.Close())
+
+	// updates after closing are not recorded
+	world.QueueCommand(&MoveCommand{Entity: e.ID(), DX: 100})
+	require.NoError(t, world.TryUpdate())
+
+	headless := NewWorld(0)
+	system := &ComponentReadingSystem{}
+	headless.AddSystem(system, false)
+	require.NoError(t, Replay(buf, headless))
+
+	assert.Equal(t, []int{1, 3, 3, -1}, system.seen)
+	assert.Equal(t, int64(3), headless.GetTurn())
+
+	replayed := headless.GetEntity(e.ID())
+	require.NotNil(t, replayed)
+	var testable *Testable
+	assert.Equal(t, -1, replayed.Component(testable).(Testable).TestComponent().X)
+	assert.Equal(t, replayed, headless.Player())
+}
+
+func TestReplayDetectsDivergence(t *testing.T) {
+	world := NewWorld(0)
+	e := NewEntity()
+	e.Add(&TestComponent{})
+	world.AddEntity(e)
+
+	buf := bytes.NewBuffer(nil)
+	recorder, err := NewRecorder(buf, world)
+	require.NoError(t, err)
+	world.QueueCommand(&MoveCommand{Entity: e.ID()})
+	require.NoError(t, world.TryUpdate())
+	require.NoError(t, recorder.Close())
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 3)
+
+	var end recordedFrame
+	require.NoError(t, json.Unmarshal(lines[2], &end))
+	end.Turn = 99
+	lines[2], err = json.Marshal(end)
+	require.NoError(t, err)
+
+	err = Replay(bytes.NewReader(bytes.Join(lines, []byte("\n"))), NewWorld(0))
+	assert.Error(t, err)
+}
+
+func TestUnfinishedRecordingIsReplayedAsFarAsItGoes(t *testing.T) {
+	world := NewWorld(0)
+	world.AddSystem(&ComponentReadingSystem{}, false)
+	e := NewEntity()
+	e.Add(&TestComponent{})
+	world.AddEntity(e)
+	world.SetPlayer(e)
+
+	buf := bytes.NewBuffer(nil)
+	_, err := NewRecorder(buf, world)
+	require.NoError(t, err)
+	world.QueueCommand(&MoveCommand{Entity: e.ID(), DX: 5})
+	require.NoError(t, world.TryUpdate())
+	// the recorder is never closed, as if the game had crashed
+
+	headless := NewWorld(0)
+	headless.AddSystem(&ComponentReadingSystem{}, false)
+	assert.Equal(t, io.ErrUnexpectedEOF, Replay(buf, headless))
+
+	var testable *Testable
+	assert.Equal(t, 5, headless.GetEntity(e.ID()).Component(testable).(Testable).TestComponent().X)
+	assert.Equal(t, world.GetTurn(), headless.GetTurn())
+}
@@ -0,0 +1,46 @@
+package ecs
+
+import (
+	"reflect"
+)
+
+// AddResource adds a world-wide singleton, such as a random number generator or game settings. Resources must be
+// struct pointers. They are saved along with the world, so must be registered with RegisterComponent.
+func (w *World) AddResource(resource interface{}) {
+	if reflect.TypeOf(resource).Kind() != reflect.Ptr {
+		panic("resources must be pointers")
+	}
+	w.resourceStore().Add(resource)
+}
+
+// RemoveResource removes a resource previously added with AddResource.
+func (w *World) RemoveResource(resource interface{}) {
+	w.resourceStore().Remove(resource)
+}
+
+// resourceStore returns the store holding the world's resources, creating it for worlds not created by NewWorld.
+func (w *World) resourceStore() *ComponentStore {
+	if w.resources == nil {
+		w.resources = &ComponentStore{ordering: w.ordering}
+	}
+	return w.resources
+}
+
+// Resource returns the first resource matching the provided interface pointer, or nil.
+func (w *World) Resource(face interface{}) interface{} {
+	interfaceType := reflect.TypeOf(face).Elem()
+	for _, r := range w.Resources() {
+		if reflect.TypeOf(r).Implements(interfaceType) {
+			return r
+		}
+	}
+	return nil
+}
+
+// Resources returns all resources in the order they were added.
+func (w *World) Resources() []interface{} {
+	if w.resources == nil {
+		return nil
+	}
+	return w.resources.List()
+}
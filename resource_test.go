@@ -0,0 +1,34 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourcesCanBeAddedAndRemoved(t *testing.T) {
+	world := NewWorld(0)
+
+	resource := &TestComponent{X: 3}
+	world.AddResource(resource)
+
+	var testable *Testable
+	assert.Equal(t, resource, world.Resource(testable))
+	assert.Equal(t, []interface{}{resource}, world.Resources())
+
+	world.RemoveResource(resource)
+	assert.Nil(t, world.Resource(testable))
+}
+
+func TestNonPointerResourcesAreRejected(t *testing.T) {
+	assert.Panics(t, func() {
+		NewWorld(0).AddResource(TestComponent{})
+	})
+}
+
+func TestResourcesCanBeAddedToAZeroValueWorld(t *testing.T) {
+	var world World
+	assert.Nil(t, world.Resource(IsTestable))
+	world.AddResource(&TestComponent{})
+	assert.Len(t, world.Resources(), 1)
+}
@@ -0,0 +1,120 @@
+package ecs
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+)
+
+// RNG is a seedable, serialisable random number generator (SplitMix64). A world has a single RNG resource, which is
+// saved and loaded with the world so that runs using the same seed and inputs produce identical results. RNG
+// implements rand.Source64, so it can be wrapped with rand.New() for the full math/rand API.
+type RNG struct {
+	Initial uint64 `json:"seed"`
+	State   uint64 `json:"state"`
+	// Streams are independent generators derived from this one, keyed by name. See Stream.
+	Streams map[string]*RNG `json:"streams,omitempty"`
+}
+
+var _ rand.Source64 = (*RNG)(nil)
+
+func init() {
+	registerBuiltin(&RNG{})
+}
+
+// NewRNG creates a random number generator with the given seed.
+func NewRNG(seed uint64) *RNG {
+	return &RNG{
+		Initial: seed,
+		State:   seed,
+	}
+}
+
+// Uint64 returns a pseudo-random 64-bit value.
+func (r *RNG) Uint64() uint64 {
+	r.State += 0x9e3779b97f4a7c15
+	return mix64(r.State)
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer.
+func (r *RNG) Int63() int64 {
+	return int64(r.Uint64() >> 1)
+}
+
+// Seed resets the generator, discarding any streams.
+func (r *RNG) Seed(seed int64) {
+	r.Initial = uint64(seed)
+	r.State = uint64(seed)
+	r.Streams = nil
+}
+
+// Intn returns a pseudo-random number in [0,n). It panics if n <= 0.
+func (r *RNG) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+	bound := uint64(n)
+	// values below 2^64 % bound are rejected, leaving a range which divides evenly into bound so that no result is
+	// favoured
+	threshold := -bound % bound
+	for {
+		if v := r.Uint64(); v >= threshold {
+			return int(v % bound)
+		}
+	}
+}
+
+// Float64 returns a pseudo-random number in [0.0,1.0).
+func (r *RNG) Float64() float64 {
+	return float64(r.Uint64()>>11) / (1 << 53)
+}
+
+// Stream returns a named generator derived from this one. A stream's sequence depends only on this generator's seed
+// and the name, so systems using their own stream are unaffected by how much other systems draw.
+func (r *RNG) Stream(name string) *RNG {
+	if stream, ok := r.Streams[name]; ok {
+		return stream
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	stream := NewRNG(mix64(r.Initial ^ h.Sum64()))
+	if r.Streams == nil {
+		r.Streams = make(map[string]*RNG)
+	}
+	r.Streams[name] = stream
+	return stream
+}
+
+// RNG returns the world's random number generator, adding one with a seed of zero if there isn't one already.
+func (w *World) RNG() *RNG {
+	for _, r := range w.Resources() {
+		if rng, ok := r.(*RNG); ok {
+			return rng
+		}
+	}
+	rng := NewRNG(0)
+	w.AddResource(rng)
+	return rng
+}
+
+// SetSeed replaces the world's random number generator with a new one using the given seed.
+func (w *World) SetSeed(seed uint64) {
+	for _, r := range w.Resources() {
+		if rng, ok := r.(*RNG); ok {
+			w.RemoveResource(rng)
+			break
+		}
+	}
+	w.AddResource(NewRNG(seed))
+}
+
+// SystemRNG returns a stream of the world's random number generator dedicated to the type of the given system.
+func (w *World) SystemRNG(system System) *RNG {
+	return w.RNG().Stream(fmt.Sprintf("%T", system))
+}
+
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
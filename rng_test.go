@@ -0,0 +1,79 @@
+package ecs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRNGIsDeterministic(t *testing.T) {
+	a := NewRNG(1234)
+	b := NewRNG(1234)
+	c := NewRNG(4321)
+
+	var fromA, fromB, fromC []uint64
+	for i := 0; i < 10; i++ {
+		fromA = append(fromA, a.Uint64())
+		fromB = append(fromB, b.Uint64())
+		fromC = append(fromC, c.Uint64())
+	}
+
+	assert.Equal(t, fromA, fromB)
+	assert.NotEqual(t, fromA, fromC)
+}
+
+func TestRNGStreamsAreIndependent(t *testing.T) {
+	a := NewRNG(1)
+	b := NewRNG(1)
+
+	// drawing from the parent and other streams should not affect a stream
+	a.Uint64()
+	a.Stream("other").Uint64()
+
+	assert.Equal(t, b.Stream("ai").Uint64(), a.Stream("ai").Uint64())
+	assert.NotEqual(t, NewRNG(1).Stream("ai").Uint64(), NewRNG(1).Stream("loot").Uint64())
+}
+
+func TestRNGStateIsSavedWithWorld(t *testing.T) {
+	world := NewWorld(0)
+	world.SetSeed(99)
+
+	system := &TestSystem{}
+	world.SystemRNG(system).Intn(10)
+	world.RNG().Float64()
+
+	data, err := json.Marshal(world)
+	require.NoError(t, err)
+
+	loaded := NewWorld(0)
+	require.NoError(t, json.Unmarshal(data, loaded))
+
+	assert.Equal(t, world.RNG(), loaded.RNG())
+	assert.Equal(t, world.RNG().Uint64(), loaded.RNG().Uint64())
+	assert.Equal(t, world.SystemRNG(system).Uint64(), loaded.SystemRNG(system).Uint64())
+}
+
+func TestRNGIntnIsInRange(t *testing.T) {
+	rng := NewRNG(5)
+	for i := 0; i < 1000; i++ {
+		n := rng.Intn(6)
+		require.True(t, n >= 0 && n < 6)
+		f := rng.Float64()
+		require.True(t, f >= 0 && f < 1)
+	}
+}
+
+func TestRNGIntnIsUnbiased(t *testing.T) {
+	// 2^64 is not a multiple of n, so a plain modulo would give the lower half of results 9/16 of the time
+	n := 1<<62 + 1<<61
+	rng := NewRNG(5)
+	var low int
+	for i := 0; i < 5000; i++ {
+		if rng.Intn(n) < n/2 {
+			low++
+		}
+	}
+	assert.InDelta(t, 2500, low, 120)
+}
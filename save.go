@@ -0,0 +1,136 @@
+package ecs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+// SaveFormat is the encoding used by World.Save and Entity.Save.
+type SaveFormat int
+
+const (
+	// FormatJSON is the same encoding as json.Marshal produces for a World or Entity.
+	FormatJSON SaveFormat = iota
+	// FormatBinary is a compact binary encoding. Component type names are stored once in a string table, UUIDs are
+	// stored as raw bytes and component data is length-prefixed.
+	FormatBinary
+	// FormatYAML is the same encoding as yaml.Marshal produces for a World or Entity. Components are keyed by name,
+	// so YAML saves are convenient for writing levels and fixtures by hand.
+	FormatYAML
+)
+
+// SaveOptions controls how a world or entity is saved. The zero value saves uncompressed JSON.
+type SaveOptions struct {
+	Format SaveFormat
+	// Compress gzips the saved data.
+	Compress bool
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Save writes the world to out, one entity at a time. The format is detected automatically by World.Load. See
+// SaveAsync to save without blocking the game loop.
+func (w *World) Save(out io.Writer, options SaveOptions) error {
+	return w.encoder(out, options).Encode(w)
+}
+
+// Load replaces the state of the world with one written by World.Save, in any format. As with World.UnmarshalJSON,
+// registered systems are kept up to date via System.Remove/System.Add.
+func (w *World) Load(in io.Reader) error {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	if data, err = verifySigned(data, w.signingKey); err != nil {
+		return err
+	}
+	return w.loadSave(data)
+}
+
+// Save writes the entity to out. The format is detected automatically by LoadEntity.
+func (e *Entity) Save(out io.Writer, options SaveOptions) error {
+	raw, err := encodeRawEntity(e)
+	if err != nil {
+		return err
+	}
+	return writeSave(out, options, func(out io.Writer) error {
+		return writeEntity(out, options.Format, raw)
+	})
+}
+
+func writeEntity(out io.Writer, format SaveFormat, e rawEntity) error {
+	switch format {
+	case FormatBinary:
+		return writeBinaryEntity(out, e)
+	case FormatYAML:
+		return writeYAMLEntity(out, e)
+	}
+	return writeJSONEntity(out, e)
+}
+
+// writeComponentList writes components in the layout of ComponentStore.MarshalJSON or ComponentStore.MarshalYAML.
+func writeComponentList(out io.Writer, format SaveFormat, components []rawComponent) error {
+	switch format {
+	case FormatBinary:
+		return errors.New("component lists cannot be written in the binary format")
+	case FormatYAML:
+		node, err := componentsToYAML(components)
+		if err != nil {
+			return err
+		}
+		return encodeYAML(out, node)
+	}
+	w := &jsonWriter{out: out}
+	w.components(components)
+	w.write("\n")
+	return w.err
+}
+
+// LoadEntity reads an entity written by Entity.Save, in any format.
+func LoadEntity(in io.Reader) (*Entity, error) {
+	return DefaultRegistry.LoadEntity(in)
+}
+
+// LoadEntity reads an entity written by Entity.Save, in any format, creating its components from the registry.
+func (r *Registry) LoadEntity(in io.Reader) (*Entity, error) {
+	return loadEntityFrom(in, r, false)
+}
+
+func writeSave(out io.Writer, options SaveOptions, encode func(out io.Writer) error) error {
+	if !options.Compress {
+		buffered := bufio.NewWriter(out)
+		if err := encode(buffered); err != nil {
+			return err
+		}
+		return buffered.Flush()
+	}
+	compressed := gzip.NewWriter(out)
+	if err := encode(compressed); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
+
+// decompress returns the save contained in data, decompressing it if necessary.
+func decompress(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	decompressed, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = decompressed.Close() }()
+	return ioutil.ReadAll(decompressed)
+}
+
+// isYAML reports whether an uncompressed, non-binary save is YAML rather than JSON. JSON saves are objects, or arrays
+// of components as written by ComponentStore.MarshalJSON.
+func isYAML(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] != '{' && trimmed[0] != '['
+}
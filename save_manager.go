@@ -0,0 +1,352 @@
+package ecs
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCorruptSave is returned when a save file fails its integrity check.
+var ErrCorruptSave = errors.New("save file is corrupt")
+
+// saveFileMagic is the first line of every file written by a SaveManager. It is followed by a line containing the
+// JSON SaveHeader, and then the world as written by World.Save.
+const saveFileMagic = "ECS-SAVE 1\n"
+
+// SaveHeader is the metadata stored at the start of a save file. It can be read with ReadSaveHeader without loading
+// the world.
+type SaveHeader struct {
+	// Name is the name of the save within its manager, e.g. "slot-1" or "autosave-0".
+	Name      string    `json:"name"`
+	Turn      int64     `json:"turn"`
+	Timestamp time.Time `json:"timestamp"`
+	// Player is a short description of the player, produced by SaveManager.Summary.
+	Player     string     `json:"player,omitempty"`
+	Format     SaveFormat `json:"format"`
+	Compressed bool       `json:"compressed"`
+	Size       int        `json:"size"`
+	// SHA256 is the hash of the world data following the header, used to detect corruption.
+	SHA256 string `json:"sha256"`
+}
+
+// SaveManager stores saves of a world in numbered slots and rotating autosaves within a directory. Files are written
+// to a temporary file and then renamed into place, so a crash whilst saving never destroys an existing save.
+type SaveManager struct {
+	dir     string
+	options SaveOptions
+	// Summary produces the player description stored in each save header. By default this is the UUID of the
+	// world's player.
+	Summary func(w *World) string
+
+	mu         sync.Mutex
+	autosaves  int
+	inProgress sync.WaitGroup
+	// lastAutosave is closed once the most recently started autosave has been written
+	lastAutosave chan struct{}
+	err          error
+}
+
+// NewSaveManager creates a manager which stores saves in dir using the given options.
+func NewSaveManager(dir string, options SaveOptions) *SaveManager {
+	return &SaveManager{
+		dir:     dir,
+		options: options,
+		Summary: func(w *World) string {
+			if player := w.Player(); player != nil {
+				return player.ID().String()
+			}
+			return ""
+		},
+	}
+}
+
+func slotName(slot int) string {
+	return fmt.Sprintf("slot-%d", slot)
+}
+
+func autosaveName(index int) string {
+	return fmt.Sprintf("autosave-%d", index)
+}
+
+func (m *SaveManager) path(name string) string {
+	return filepath.Join(m.dir, name+".sav")
+}
+
+// Save writes the world to a numbered slot, replacing any existing save in that slot.
+func (m *SaveManager) Save(w *World, slot int) error {
+	body := bytes.NewBuffer(nil)
+	if err := w.Save(body, m.options); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.write(slotName(slot), m.header(w), body.Bytes())
+}
+
+// Load verifies and loads the save in a numbered slot into the world.
+func (m *SaveManager) Load(w *World, slot int) error {
+	return m.load(w, slotName(slot))
+}
+
+// LoadAutosave verifies and loads an autosave into the world. Index 0 is the most recent autosave.
+func (m *SaveManager) LoadAutosave(w *World, index int) error {
+	return m.load(w, autosaveName(index))
+}
+
+// Delete removes the save in a numbered slot.
+func (m *SaveManager) Delete(slot int) error {
+	return os.Remove(m.path(slotName(slot)))
+}
+
+// Headers returns the headers of all saves and autosaves in the manager's directory, ordered by name.
+func (m *SaveManager) Headers() ([]*SaveHeader, error) {
+	paths, err := filepath.Glob(filepath.Join(m.dir, "*.sav"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var headers []*SaveHeader
+	for _, path := range paths {
+		header, err := ReadSaveHeader(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
+// EnableAutosave saves the world every n turns (as counted by UseTurn), keeping the most recent keep autosaves. The
+// world and its header are captured together at the end of the update in which the turn was used, and written in the
+// background, one autosave at a time. Errors can be retrieved with Wait.
+func (m *SaveManager) EnableAutosave(w *World, every int64, keep int) {
+	m.mu.Lock()
+	m.autosaves = keep
+	m.mu.Unlock()
+	w.OnTurn(func(turn int64) {
+		if every <= 0 || turn%every != 0 {
+			return
+		}
+		w.afterUpdate(func() {
+			header := m.header(w)
+			frozen, err := freeze(w)
+			m.autosave(w, header, frozen, err)
+		})
+	})
+}
+
+// autosave encodes and writes a captured world in the background, once any earlier autosaves have been written.
+func (m *SaveManager) autosave(w *World, header *SaveHeader, frozen *frozenWorld, err error) {
+	m.mu.Lock()
+	previous := m.lastAutosave
+	done := make(chan struct{})
+	m.lastAutosave = done
+	m.mu.Unlock()
+	m.inProgress.Add(1)
+	go func() {
+		defer m.inProgress.Done()
+		defer close(done)
+		if previous != nil {
+			<-previous
+		}
+		body := bytes.NewBuffer(nil)
+		if err == nil {
+			err = w.encoder(body, m.options).encode(frozen)
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if err == nil {
+			err = m.rotate(header, body.Bytes())
+		}
+		if err != nil && m.err == nil {
+			m.err = err
+		}
+	}()
+}
+
+// Wait waits for any autosaves in progress, and returns (and clears) the first autosave error encountered.
+func (m *SaveManager) Wait() error {
+	m.inProgress.Wait()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	err := m.err
+	m.err = nil
+	return err
+}
+
+func (m *SaveManager) header(w *World) *SaveHeader {
+	var summary string
+	if m.Summary != nil {
+		summary = m.Summary(w)
+	}
+	return &SaveHeader{
+		Turn:       w.GetTurn(),
+		Timestamp:  time.Now().UTC(),
+		Player:     summary,
+		Format:     m.options.Format,
+		Compressed: m.options.Compress,
+	}
+}
+
+// rotate shifts the existing autosaves along by one, dropping the oldest, and writes the new autosave in first place.
+func (m *SaveManager) rotate(header *SaveHeader, body []byte) error {
+	if m.autosaves <= 0 {
+		return nil
+	}
+	_ = os.Remove(m.path(autosaveName(m.autosaves - 1)))
+	for i := m.autosaves - 2; i >= 0; i-- {
+		if err := os.Rename(m.path(autosaveName(i)), m.path(autosaveName(i+1))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return m.write(autosaveName(0), header, body)
+}
+
+// write atomically writes a save file by writing to a temporary file in the same directory and renaming it.
+func (m *SaveManager) write(name string, header *SaveHeader, body []byte) error {
+	header.Name = name
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(m.dir, "."+name+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	buffered := bufio.NewWriter(tmp)
+	err = writeSaveFile(buffered, header, body)
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), m.path(name)); err != nil {
+		return err
+	}
+	return syncDir(m.dir)
+}
+
+// writeSaveFile writes the magic line, the header and the body of a save file. The size and hash of the header are set
+// from the body.
+func writeSaveFile(out io.Writer, header *SaveHeader, body []byte) error {
+	sum := sha256.Sum256(body)
+	header.Size = len(body)
+	header.SHA256 = hex.EncodeToString(sum[:])
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(out, saveFileMagic); err != nil {
+		return err
+	}
+	if _, err := out.Write(append(headerData, '\n')); err != nil {
+		return err
+	}
+	_, err = out.Write(body)
+	return err
+}
+
+// readSaveFile reads the header and body of a save file, checking the body against the header.
+func readSaveFile(in io.Reader) (*SaveHeader, []byte, error) {
+	reader := bufio.NewReader(in)
+	header, err := readSaveHeader(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := header.verify(body); err != nil {
+		return nil, nil, err
+	}
+	return header, body, nil
+}
+
+// syncDir flushes a directory to disk, so that files renamed into it survive a crash.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		// directories cannot be opened for syncing on Windows, where renames are flushed by the file system
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = d.Close() }()
+	return d.Sync()
+}
+
+func (m *SaveManager) load(w *World, name string) error {
+	f, err := os.Open(m.path(name))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	_, body, err := readSaveFile(f)
+	if err != nil {
+		return err
+	}
+	return w.Load(bytes.NewReader(body))
+}
+
+// ReadSaveHeader reads the header of a save file written by a SaveManager, without reading the rest of the file.
+func ReadSaveHeader(path string) (*SaveHeader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return readSaveHeader(bufio.NewReader(f))
+}
+
+func readSaveHeader(reader *bufio.Reader) (*SaveHeader, error) {
+	magic, err := reader.ReadString('\n')
+	if err != nil || magic != saveFileMagic {
+		return nil, fmt.Errorf("%w: not a save file", ErrCorruptSave)
+	}
+	line, err := reader.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	var header SaveHeader
+	if err := json.Unmarshal(bytes.TrimSpace(line), &header); err != nil {
+		return nil, fmt.Errorf("%w: invalid header: %s", ErrCorruptSave, err)
+	}
+	return &header, nil
+}
+
+func (h *SaveHeader) verify(body []byte) error {
+	if len(body) != h.Size {
+		return fmt.Errorf("%w: expected %d bytes of data, found %d", ErrCorruptSave, h.Size, len(body))
+	}
+	sum := sha256.Sum256(body)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), h.SHA256) {
+		return fmt.Errorf("%w: checksum mismatch", ErrCorruptSave)
+	}
+	return nil
+}
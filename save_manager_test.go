@@ -0,0 +1,144 @@
+package ecs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempSaveDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "ecs-saves")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+func TestSaveManagerSlotsRoundTrip(t *testing.T) {
+	dir := tempSaveDir(t)
+	manager := NewSaveManager(dir, SaveOptions{Format: FormatBinary, Compress: true})
+
+	world := buildSaveWorld(5)
+	require.NoError(t, manager.Save(world, 1))
+
+	header, err := ReadSaveHeader(filepath.Join(dir, "slot-1.sav"))
+	require.NoError(t, err)
+	assert.Equal(t, "slot-1", header.Name)
+	assert.Equal(t, int64(12), header.Turn)
+	assert.Equal(t, world.Player().ID().String(), header.Player)
+	assert.Equal(t, FormatBinary, header.Format)
+	assert.False(t, header.Timestamp.IsZero())
+
+	loaded := NewWorld(0)
+	require.NoError(t, manager.Load(loaded, 1))
+	divergence, err := CompareWorlds(world, loaded)
+	require.NoError(t, err)
+	assert.Nil(t, divergence)
+
+	require.NoError(t, manager.Delete(1))
+	assert.Error(t, manager.Load(loaded, 1))
+}
+
+func TestSaveManagerLeavesNoTemporaryFiles(t *testing.T) {
+	dir := tempSaveDir(t)
+	manager := NewSaveManager(dir, SaveOptions{})
+
+	require.NoError(t, manager.Save(buildSaveWorld(1), 1))
+	require.NoError(t, manager.Save(buildSaveWorld(2), 1))
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "slot-1.sav", files[0].Name())
+}
+
+func TestSaveManagerDetectsCorruption(t *testing.T) {
+	dir := tempSaveDir(t)
+	manager := NewSaveManager(dir, SaveOptions{})
+	require.NoError(t, manager.Save(buildSaveWorld(3), 2))
+
+	path := filepath.Join(dir, "slot-2.sav")
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)-10] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(path, data, 0o600))
+
+	err = manager.Load(NewWorld(0), 2)
+	assert.True(t, errors.Is(err, ErrCorruptSave))
+
+	require.NoError(t, ioutil.WriteFile(path, data[:len(data)/2], 0o600))
+	err = manager.Load(NewWorld(0), 2)
+	assert.True(t, errors.Is(err, ErrCorruptSave))
+}
+
+type TurnUsingSystem struct {
+	TestSystem
+}
+
+func (s *TurnUsingSystem) Update(w *World, _ *Entity) {
+	w.UseTurn()
+}
+
+func TestSaveManagerRotatesAutosaves(t *testing.T) {
+	dir := tempSaveDir(t)
+	manager := NewSaveManager(dir, SaveOptions{})
+
+	world := NewWorld(0)
+	world.AddSystem(&TurnUsingSystem{}, false)
+	manager.EnableAutosave(world, 2, 3)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, world.TryUpdate())
+		require.NoError(t, manager.Wait())
+	}
+
+	headers, err := manager.Headers()
+	require.NoError(t, err)
+	require.Len(t, headers, 3)
+	assert.Equal(t, "autosave-0", headers[0].Name)
+	assert.Equal(t, int64(10), headers[0].Turn)
+	assert.Equal(t, int64(8), headers[1].Turn)
+	assert.Equal(t, int64(6), headers[2].Turn)
+
+	loaded := NewWorld(0)
+	require.NoError(t, manager.LoadAutosave(loaded, 1))
+	assert.Equal(t, int64(8), loaded.GetTurn())
+}
+
+type DoubleTurnSystem struct {
+	TestSystem
+}
+
+func (s *DoubleTurnSystem) Update(w *World, _ *Entity) {
+	w.UseTurn()
+	w.UseTurn()
+}
+
+func TestAutosaveHeadersDescribeTheSavedTurn(t *testing.T) {
+	dir := tempSaveDir(t)
+	manager := NewSaveManager(dir, SaveOptions{})
+
+	world := NewWorld(0)
+	world.AddSystem(&DoubleTurnSystem{}, false)
+	manager.EnableAutosave(world, 1, 4)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, world.TryUpdate())
+	}
+	require.NoError(t, manager.Wait())
+
+	headers, err := manager.Headers()
+	require.NoError(t, err)
+	require.Len(t, headers, 4)
+	for i, header := range headers {
+		loaded := NewWorld(0)
+		require.NoError(t, manager.LoadAutosave(loaded, i))
+		assert.Equal(t, header.Turn, loaded.GetTurn())
+	}
+	// autosaves are rotated in the order they were taken
+	assert.Equal(t, []int64{4, 4, 2, 2}, []int64{headers[0].Turn, headers[1].Turn, headers[2].Turn, headers[3].Turn})
+}
@@ -0,0 +1,239 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var saveOptions = []SaveOptions{
+	{Format: FormatJSON},
+	{Format: FormatJSON, Compress: true},
+	{Format: FormatBinary},
+	{Format: FormatBinary, Compress: true},
+	{Format: FormatYAML},
+	{Format: FormatYAML, Compress: true},
+}
+
+func buildSaveWorld(entities int) *World {
+	world := NewWorld(12)
+	world.SetSeed(4)
+	world.SetIDGenerator(NewSequentialIDGenerator(4))
+	for i := 0; i < entities; i++ {
+		e := world.NewEntity()
+		e.Add(&TestComponent{X: i})
+		e.Add(&NestedComponent{Name: fmt.Sprintf("entity %d", i), Tags: []string{"a", "b"}})
+		world.AddEntity(e)
+	}
+	world.SetPlayer(world.GetEntities()[0])
+	return world
+}
+
+func TestWorldSaveRoundTrip(t *testing.T) {
+	for _, options := range saveOptions {
+		t.Run(fmt.Sprintf("%d-%t", options.Format, options.Compress), func(t *testing.T) {
+			world := buildSaveWorld(10)
+
+			buf := bytes.NewBuffer(nil)
+			require.NoError(t, world.Save(buf, options))
+
+			loaded := NewWorld(0)
+			system := &TestSystem{}
+			loaded.AddSystem(system, false)
+			require.NoError(t, loaded.Load(buf))
+
+			divergence, err := CompareWorlds(world, loaded)
+			require.NoError(t, err)
+			assert.Nil(t, divergence)
+			assert.Len(t, system.addedEntities, 10)
+			assert.Equal(t, world.Player().ID(), loaded.Player().ID())
+		})
+	}
+}
+
+func TestEntitySaveRoundTrip(t *testing.T) {
+	for _, options := range saveOptions {
+		t.Run(fmt.Sprintf("%d-%t", options.Format, options.Compress), func(t *testing.T) {
+			e := NewEntity()
+			e.Add(&TestComponent{X: 3})
+			e.Add(&NestedComponent{Name: "thing"})
+
+			buf := bytes.NewBuffer(nil)
+			require.NoError(t, e.Save(buf, options))
+
+			loaded, err := LoadEntity(buf)
+			require.NoError(t, err)
+			assert.Equal(t, e, loaded)
+		})
+	}
+}
+
+func TestBinarySaveIsSmallerThanJSON(t *testing.T) {
+	world := buildSaveWorld(100)
+
+	jsonSave := bytes.NewBuffer(nil)
+	require.NoError(t, world.Save(jsonSave, SaveOptions{Format: FormatJSON}))
+	binarySave := bytes.NewBuffer(nil)
+	require.NoError(t, world.Save(binarySave, SaveOptions{Format: FormatBinary}))
+
+	assert.Less(t, binarySave.Len(), jsonSave.Len())
+}
+
+func TestTruncatedBinarySaveFails(t *testing.T) {
+	world := buildSaveWorld(3)
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, world.Save(buf, SaveOptions{Format: FormatBinary}))
+
+	data := buf.Bytes()
+	for _, size := range []int{5, 10, len(data) / 2, len(data) - 1} {
+		assert.Error(t, NewWorld(0).Load(bytes.NewReader(data[:size])))
+	}
+}
+
+func TestLoadingEntityFromWorldSaveFails(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, buildSaveWorld(1).Save(buf, SaveOptions{Format: FormatBinary}))
+
+	_, err := LoadEntity(buf)
+	assert.Error(t, err)
+}
+
+func benchmarkSave(b *testing.B, options SaveOptions) {
+	world := buildSaveWorld(10000)
+	buf := bytes.NewBuffer(nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := world.Save(buf, options); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkLoad(b *testing.B, options SaveOptions) {
+	buf := bytes.NewBuffer(nil)
+	if err := buildSaveWorld(10000).Save(buf, options); err != nil {
+		b.Fatal(err)
+	}
+	data := buf.Bytes()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := NewWorld(0).Load(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSavingJSON(b *testing.B) {
+	benchmarkSave(b, SaveOptions{Format: FormatJSON})
+}
+
+func BenchmarkSavingBinary(b *testing.B) {
+	benchmarkSave(b, SaveOptions{Format: FormatBinary})
+}
+
+func BenchmarkLoadingJSON(b *testing.B) {
+	benchmarkLoad(b, SaveOptions{Format: FormatJSON})
+}
+
+func BenchmarkLoadingBinary(b *testing.B) {
+	benchmarkLoad(b, SaveOptions{Format: FormatBinary})
+}
+
+func TestDisabledEntitiesAreSaved(t *testing.T) {
+	for _, options := range saveOptions {
+		t.Run(fmt.Sprintf("%d-%t", options.Format, options.Compress), func(t *testing.T) {
+			world := buildSaveWorld(3)
+			disabled := world.GetEntities()[1]
+			world.SetEntityEnabled(disabled, false)
+
+			buf := bytes.NewBuffer(nil)
+			require.NoError(t, world.Save(buf, options))
+
+			loaded := NewWorld(0)
+			system := &TestSystem{}
+			loaded.AddSystem(system, false)
+			require.NoError(t, loaded.Load(buf))
+
+			divergence, err := CompareWorlds(world, loaded)
+			require.NoError(t, err)
+			assert.Nil(t, divergence)
+			assert.Len(t, system.addedEntities, 2)
+			require.Len(t, loaded.DisabledEntities(), 1)
+			assert.Equal(t, disabled.ID(), loaded.DisabledEntities()[0].ID())
+		})
+	}
+}
+
+func TestDisabledEntitySaveMatchesMarshalJSON(t *testing.T) {
+	world := buildSaveWorld(2)
+	world.SetEntityEnabled(world.GetEntities()[0], false)
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, world.Save(buf, SaveOptions{Format: FormatJSON}))
+	marshalled, err := json.Marshal(world)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(marshalled), buf.String())
+	assert.Contains(t, buf.String(), `"disabled":true`)
+}
+
+func TestEntitySaveKeepsDisabledState(t *testing.T) {
+	for _, options := range saveOptions {
+		t.Run(fmt.Sprintf("%d-%t", options.Format, options.Compress), func(t *testing.T) {
+			e := NewEntity()
+			e.Add(&TestComponent{X: 3})
+			e.Disabled = true
+
+			buf := bytes.NewBuffer(nil)
+			require.NoError(t, e.Save(buf, options))
+
+			loaded, err := LoadEntity(buf)
+			require.NoError(t, err)
+			assert.Equal(t, e, loaded)
+		})
+	}
+}
+
+func TestVersion1BinaryEntitiesCanBeLoaded(t *testing.T) {
+	id := NewEntity().ID()
+	data := append([]byte("ECSB\x01E"), 2)
+	for _, name := range []string{"TestComponent", "HealthComponent"} {
+		data = append(append(data, byte(len(name))), name...)
+	}
+	data = append(data, id[:]...)
+	// two components, the first using the second name in the string table
+	data = append(data, 2, 1, byte(len(`{"hp":4}`)))
+	data = append(data, `{"hp":4}`...)
+	data = append(data, 0, byte(len(`{"X":7}`)))
+	data = append(data, `{"X":7}`...)
+
+	e, err := LoadEntity(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, id, e.ID())
+	assert.Equal(t, []interface{}{&HealthComponent{HP: 4}, &TestComponent{X: 7}}, e.Store.List())
+}
+
+func TestVersion1BinarySavesCanBeLoaded(t *testing.T) {
+	id := NewEntity().ID()
+	data := append([]byte("ECSB\x01W"), 2)
+	for _, name := range []string{"HealthComponent", "TestComponent"} {
+		data = append(append(data, byte(len(name))), name...)
+	}
+	// turn 3, no controllers, no resources, one entity with one component using the second name in the string table
+	data = append(data, 6, 0, 0, 1)
+	data = append(data, id[:]...)
+	data = append(data, 1, 1, byte(len(`{"X":7}`)))
+	data = append(data, `{"X":7}`...)
+
+	world := NewWorld(0)
+	require.NoError(t, world.Load(bytes.NewReader(data)))
+	assert.Equal(t, int64(3), world.GetTurn())
+	e := world.GetEntity(id)
+	require.NotNil(t, e)
+	assert.False(t, e.Disabled)
+	assert.Equal(t, []interface{}{&TestComponent{X: 7}}, e.Store.List())
+}
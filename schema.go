@@ -0,0 +1,451 @@
+package ecs
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// schemaVersion is the JSON Schema draft used by generated schemas. Draft 7 is the most widely supported by editors.
+const schemaVersion = "http://json-schema.org/draft-07/schema#"
+
+// Definitions used by EntitySchema and WorldSchema for the parts of a save which are not components. The prefix keeps
+// them apart from component definitions, which are named after their component.
+const (
+	entityDefinition     = "ecs.Entity"
+	componentsDefinition = "ecs.Components"
+)
+
+// Schema is a JSON Schema document, or a schema nested within one.
+type Schema struct {
+	Schema          string             `json:"$schema,omitempty"`
+	Ref             string             `json:"$ref,omitempty"`
+	Title           string             `json:"title,omitempty"`
+	Description     string             `json:"description,omitempty"`
+	Type            string             `json:"type,omitempty"`
+	Format          string             `json:"format,omitempty"`
+	ContentEncoding string             `json:"contentEncoding,omitempty"`
+	Const           interface{}        `json:"const,omitempty"`
+	Properties      map[string]*Schema `json:"properties,omitempty"`
+	Required        []string           `json:"required,omitempty"`
+	// AdditionalProperties is either a *Schema describing the values of an object, or false if the object may only
+	// contain the listed properties.
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Definitions          map[string]*Schema `json:"definitions,omitempty"`
+}
+
+// ComponentSchemas returns a schema for the data of each component in DefaultRegistry, keyed by component name. See
+// Registry.ComponentSchemas.
+func ComponentSchemas() (map[string]*Schema, error) {
+	return DefaultRegistry.ComponentSchemas()
+}
+
+// EntitySchema returns a schema for an entity, allowing any component in DefaultRegistry. See Registry.EntitySchema.
+func EntitySchema() (*Schema, error) {
+	return DefaultRegistry.EntitySchema()
+}
+
+// WorldSchema returns a schema for a world, allowing any component in DefaultRegistry. See Registry.WorldSchema.
+func WorldSchema() (*Schema, error) {
+	return DefaultRegistry.WorldSchema()
+}
+
+// ComponentSchemas returns a schema for the data of each component in the registry, keyed by component name.
+// Properties are named after their json tags, and properties without omitempty are required, as they are always
+// present in saves. Components saved by a codec are described as base64 strings.
+func (r *Registry) ComponentSchemas() (map[string]*Schema, error) {
+	schemas := make(map[string]*Schema)
+	for _, t := range r.types {
+		g := newSchemaGenerator(r)
+		if err := g.component(t); err != nil {
+			return nil, err
+		}
+		root := *g.definitions[typeName(t)]
+		root.Schema = schemaVersion
+		root.Title = typeName(t)
+		root.Definitions = g.definitions
+		schemas[typeName(t)] = &root
+	}
+	return schemas, nil
+}
+
+// EntitySchema returns a schema for an entity as saved by json.Marshal or Entity.Save, allowing any component in the
+// registry.
+func (r *Registry) EntitySchema() (*Schema, error) {
+	g := newSchemaGenerator(r)
+	if err := g.saveDefinitions(); err != nil {
+		return nil, err
+	}
+	root := *g.definitions[entityDefinition]
+	root.Schema = schemaVersion
+	root.Title = "Entity"
+	root.Definitions = g.definitions
+	return &root, nil
+}
+
+// WorldSchema returns a schema for a world as saved by json.Marshal or World.Save, allowing any component in the
+// registry. World.Registry gives the registry used to load a particular world.
+func (r *Registry) WorldSchema() (*Schema, error) {
+	g := newSchemaGenerator(r)
+	if err := g.saveDefinitions(); err != nil {
+		return nil, err
+	}
+	return &Schema{
+		Schema: schemaVersion,
+		Title:  "World",
+		Type:   "object",
+		Properties: map[string]*Schema{
+			"turn": {Type: "integer"},
+			"controllers": {
+				Type: "array",
+				Items: &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"name":   {Type: "string"},
+						"entity": uuidSchema(),
+					},
+					Required:             []string{"name", "entity"},
+					AdditionalProperties: false,
+				},
+			},
+			"resources": {Ref: definitionRef(componentsDefinition)},
+			"entities": {
+				Type:  "array",
+				Items: &Schema{Ref: definitionRef(entityDefinition)},
+			},
+		},
+		Required:             []string{"turn", "entities"},
+		AdditionalProperties: false,
+		Definitions:          g.definitions,
+	}, nil
+}
+
+func definitionRef(name string) string {
+	return "#/definitions/" + name
+}
+
+func uuidSchema() *Schema {
+	return &Schema{Type: "string", Format: "uuid"}
+}
+
+// schemaGenerator builds schemas for Go types. Named struct types are described once in definitions and referenced
+// elsewhere, which also allows for recursive types.
+type schemaGenerator struct {
+	registry    *Registry
+	definitions map[string]*Schema
+	names       map[reflect.Type]string
+	taken       map[string]bool
+}
+
+func newSchemaGenerator(registry *Registry) *schemaGenerator {
+	g := &schemaGenerator{
+		registry:    registry,
+		definitions: make(map[string]*Schema),
+		names:       make(map[reflect.Type]string),
+		taken:       map[string]bool{entityDefinition: true, componentsDefinition: true},
+	}
+	// components are always defined under their registered name
+	for _, t := range registry.types {
+		g.names[t] = typeName(t)
+		g.taken[typeName(t)] = true
+	}
+	return g
+}
+
+// saveDefinitions defines every registered component, along with an entity and the list of components it holds.
+func (g *schemaGenerator) saveDefinitions() error {
+	var entries []*Schema
+	for _, t := range g.registry.types {
+		if err := g.component(t); err != nil {
+			return err
+		}
+		entry := &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"type": {Const: typeName(t)},
+				"data": {Ref: definitionRef(typeName(t))},
+			},
+			Required:             []string{"type", "data"},
+			AdditionalProperties: false,
+		}
+		if hasCodec(t) {
+			entry.Properties["encoding"] = &Schema{Const: encodingCodec}
+			entry.Required = append(entry.Required, "encoding")
+		}
+		entries = append(entries, entry)
+	}
+	g.definitions[componentsDefinition] = &Schema{
+		Type:  "array",
+		Items: &Schema{OneOf: entries},
+	}
+	g.definitions[entityDefinition] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"uuid":       uuidSchema(),
+			"disabled":   {Type: "boolean"},
+			"components": {Ref: definitionRef(componentsDefinition)},
+		},
+		Required:             []string{"uuid", "components"},
+		AdditionalProperties: false,
+	}
+	return nil
+}
+
+// component defines a registered component under its name.
+func (g *schemaGenerator) component(t reflect.Type) error {
+	if hasCodec(t) {
+		g.definitions[typeName(t)] = &Schema{
+			Type:            "string",
+			ContentEncoding: "base64",
+			Description:     "Encoded by the component's codec.",
+		}
+		return nil
+	}
+	s, err := g.schema(t)
+	if err != nil {
+		return err
+	}
+	if _, defined := g.definitions[typeName(t)]; !defined {
+		// components which are not structs are described inline by schema
+		g.definitions[typeName(t)] = s
+	}
+	return nil
+}
+
+func hasCodec(t reflect.Type) bool {
+	_, registered := componentCodecs[t]
+	return registered || reflect.PtrTo(t).Implements(reflect.TypeOf((*ComponentCodec)(nil)).Elem())
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schema describes values of type t as they are encoded by encoding/json.
+func (g *schemaGenerator) schema(t reflect.Type) (*Schema, error) {
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	case reflect.TypeOf(uuid.UUID{}):
+		return uuidSchema(), nil
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		// the encoding is unknown, so anything is allowed
+		return &Schema{}, nil
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Ptr:
+		elem, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{AnyOf: []*Schema{elem, {Type: "null"}}}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", ContentEncoding: "base64"}, nil
+		}
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.named(t)
+	}
+	return nil, fmt.Errorf("cannot describe %s: values of kind %s cannot be saved as JSON", t, t.Kind())
+}
+
+// named returns a reference to the definition of a named struct type, defining it if necessary.
+func (g *schemaGenerator) named(t reflect.Type) (*Schema, error) {
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		if g.taken[name] {
+			name = t.String()
+		}
+		g.names[t] = name
+		g.taken[name] = true
+	}
+	if _, defined := g.definitions[name]; !defined {
+		// reserve the definition before building it, so recursive references stop here
+		g.definitions[name] = nil
+		definition, err := g.structSchema(t)
+		if err != nil {
+			return nil, err
+		}
+		g.definitions[name] = definition
+	}
+	return &Schema{Ref: definitionRef(name)}, nil
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) (*Schema, error) {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+	if err := g.addFields(s, t); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// jsonField is a field which encoding/json saves as part of a struct, possibly promoted from an embedded struct.
+type jsonField struct {
+	name    string
+	options map[string]bool
+	field   reflect.StructField
+	owner   reflect.Type
+	depth   int
+	tagged  bool
+}
+
+// addFields adds the fields of a struct to s, following the rules used by encoding/json. Fields of embedded structs
+// are promoted; where names clash, the shallowest field is used, then the only tagged field, and otherwise none.
+func (g *schemaGenerator) addFields(s *Schema, t reflect.Type) error {
+	var fields []jsonField
+	collectFields(t, 0, make(map[reflect.Type]bool), &fields)
+
+	var names []string
+	byName := make(map[string][]jsonField)
+	for _, f := range fields {
+		if _, seen := byName[f.name]; !seen {
+			names = append(names, f.name)
+		}
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	for _, name := range names {
+		f, ok := dominantField(byName[name])
+		if !ok {
+			continue
+		}
+		var property *Schema
+		if f.options["string"] {
+			property = &Schema{Type: "string"}
+		} else {
+			var err error
+			if property, err = g.schema(f.field.Type); err != nil {
+				return fmt.Errorf("%s.%s: %w", f.owner.Name(), f.field.Name, err)
+			}
+		}
+		s.Properties[name] = property
+		if !f.options["omitempty"] {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return nil
+}
+
+// collectFields appends the fields of a struct, and those promoted from its embedded structs, to fields.
+func collectFields(t reflect.Type, depth int, visiting map[reflect.Type]bool, fields *[]jsonField) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options := parseJSONTag(tag)
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				collectFields(fieldType, depth+1, visiting, fields)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		tagged := name != ""
+		if !tagged {
+			name = field.Name
+		}
+		*fields = append(*fields, jsonField{
+			name:    name,
+			options: options,
+			field:   field,
+			owner:   t,
+			depth:   depth,
+			tagged:  tagged,
+		})
+	}
+}
+
+// dominantField returns the field which encoding/json saves from fields sharing a name, if there is one.
+func dominantField(fields []jsonField) (jsonField, bool) {
+	depth := fields[0].depth
+	for _, f := range fields {
+		if f.depth < depth {
+			depth = f.depth
+		}
+	}
+	var shallowest, tagged []jsonField
+	for _, f := range fields {
+		if f.depth != depth {
+			continue
+		}
+		shallowest = append(shallowest, f)
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	if len(shallowest) == 1 {
+		return shallowest[0], true
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return jsonField{}, false
+}
+
+func parseJSONTag(tag string) (string, map[string]bool) {
+	parts := strings.Split(tag, ",")
+	options := make(map[string]bool)
+	for _, option := range parts[1:] {
+		options[option] = true
+	}
+	return parts[0], options
+}
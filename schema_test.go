@@ -0,0 +1,189 @@
+package ecs
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaBase struct {
+	Owner uuid.UUID `json:"owner"`
+}
+
+type SchemaComponent struct {
+	schemaBase
+	Created  time.Time `json:"created"`
+	Count    int64     `json:"count,string"`
+	Ignored  string    `json:"-"`
+	Untagged bool
+	Optional *float64 `json:"optional,omitempty"`
+	Raw      []byte   `json:"raw,omitempty"`
+	hidden   int
+}
+
+func init() {
+	RegisterComponent(&SchemaComponent{})
+}
+
+func TestComponentSchemasDescribeJSONEncoding(t *testing.T) {
+	schemas, err := ComponentSchemas()
+	require.NoError(t, err)
+
+	s := schemas["SchemaComponent"]
+	require.NotNil(t, s)
+	assert.Equal(t, schemaVersion, s.Schema)
+	assert.Equal(t, "object", s.Type)
+	assert.Equal(t, false, s.AdditionalProperties)
+	assert.Equal(t, map[string]*Schema{
+		"owner":    {Type: "string", Format: "uuid"},
+		"created":  {Type: "string", Format: "date-time"},
+		"count":    {Type: "string"},
+		"Untagged": {Type: "boolean"},
+		"optional": {AnyOf: []*Schema{{Type: "number"}, {Type: "null"}}},
+		"raw":      {Type: "string", ContentEncoding: "base64"},
+	}, s.Properties)
+	assert.ElementsMatch(t, []string{"owner", "created", "count", "Untagged"}, s.Required)
+}
+
+func TestSchemasDescribeTheComponentsOfTheirRegistry(t *testing.T) {
+	type Loot struct {
+		Gold int `json:"gold"`
+	}
+	registry := NewRegistry()
+	registry.Register(&Loot{})
+
+	schemas, err := registry.ComponentSchemas()
+	require.NoError(t, err)
+	assert.Contains(t, schemas, "Loot")
+	assert.Contains(t, schemas, "ecs.RNG")
+	assert.NotContains(t, schemas, "SchemaComponent")
+
+	s, err := registry.WorldSchema()
+	require.NoError(t, err)
+	assert.Contains(t, s.Definitions, "Loot")
+	assert.NotContains(t, s.Definitions, "SchemaComponent")
+
+	s, err = registry.EntitySchema()
+	require.NoError(t, err)
+	assert.Contains(t, s.Definitions, "Loot")
+}
+
+func TestComponentSchemasSupportRecursiveTypes(t *testing.T) {
+	schemas, err := ComponentSchemas()
+	require.NoError(t, err)
+
+	s := schemas["NestedComponent"]
+	require.NotNil(t, s)
+	assert.Equal(t, &Schema{AnyOf: []*Schema{{Ref: "#/definitions/NestedComponent"}, {Type: "null"}}}, s.Properties["ref"])
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "integer"}}, s.Properties["stats"])
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Type: "string"}}, s.Properties["tags"])
+	assertRefsResolve(t, s, s)
+}
+
+func TestComponentSchemasDescribeCodecsAsBase64(t *testing.T) {
+	schemas, err := ComponentSchemas()
+	require.NoError(t, err)
+
+	for _, name := range []string{"GridComponent", "BitfieldComponent"} {
+		require.Contains(t, schemas, name)
+		assert.Equal(t, "string", schemas[name].Type)
+		assert.Equal(t, "base64", schemas[name].ContentEncoding)
+	}
+}
+
+func TestEntitySchemaAllowsEveryRegisteredComponent(t *testing.T) {
+	s, err := EntitySchema()
+	require.NoError(t, err)
+	assertRefsResolve(t, s, s)
+
+	entries := s.Definitions[componentsDefinition].Items.OneOf
+	require.Len(t, entries, len(DefaultRegistry.types))
+	for i, entry := range entries {
+		name := typeName(DefaultRegistry.types[i])
+		assert.Equal(t, name, entry.Properties["type"].Const)
+		assert.Equal(t, "#/definitions/"+name, entry.Properties["data"].Ref)
+		assert.Equal(t, hasCodec(DefaultRegistry.types[i]), entry.Properties["encoding"] != nil)
+	}
+
+	_, err = json.Marshal(s)
+	assert.NoError(t, err)
+}
+
+func TestWorldSchemaRefsResolve(t *testing.T) {
+	s, err := WorldSchema()
+	require.NoError(t, err)
+	assertRefsResolve(t, s, s)
+	assert.Equal(t, "#/definitions/"+entityDefinition, s.Properties["entities"].Items.Ref)
+}
+
+func TestSchemaOfUnsaveableTypeFails(t *testing.T) {
+	_, err := newSchemaGenerator(DefaultRegistry).schema(reflect.TypeOf(struct {
+		Updates chan int
+	}{}))
+	assert.Error(t, err)
+}
+
+func assertRefsResolve(t *testing.T, root *Schema, s *Schema) {
+	if s == nil {
+		return
+	}
+	if s.Ref != "" {
+		assert.Contains(t, root.Definitions, strings.TrimPrefix(s.Ref, "#/definitions/"))
+	}
+	children := append(append([]*Schema{s.Items}, s.AnyOf...), s.OneOf...)
+	for _, property := range s.Properties {
+		children = append(children, property)
+	}
+	if additional, ok := s.AdditionalProperties.(*Schema); ok {
+		children = append(children, additional)
+	}
+	if s == root {
+		for _, definition := range s.Definitions {
+			children = append(children, definition)
+		}
+	}
+	for _, child := range children {
+		assertRefsResolve(t, root, child)
+	}
+}
+
+type schemaShadowed struct {
+	Name  int `json:"name"`
+	Level int
+	Left  int `json:"clash"`
+}
+
+type schemaClashing struct {
+	Right string `json:"clash"`
+}
+
+type schemaShadowing struct {
+	schemaShadowed
+	*schemaClashing
+	Name string `json:"name"`
+}
+
+func TestSchemaResolvesEmbeddedFieldsAsEncodingJSONDoes(t *testing.T) {
+	s, err := newSchemaGenerator(DefaultRegistry).structSchema(reflect.TypeOf(schemaShadowing{}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*Schema{
+		"name":  {Type: "string"},
+		"Level": {Type: "integer"},
+	}, s.Properties)
+
+	// the schema should describe exactly what encoding/json writes
+	data, err := json.Marshal(schemaShadowing{schemaClashing: &schemaClashing{}})
+	require.NoError(t, err)
+	var saved map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &saved))
+	for name := range saved {
+		assert.Contains(t, s.Properties, name)
+	}
+	assert.Len(t, saved, len(s.Properties))
+}
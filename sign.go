@@ -0,0 +1,79 @@
+package ecs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+)
+
+// signaturePrefix starts the first line of a signed save. The rest of the line is the hex encoded HMAC-SHA256 of the
+// save which follows it. The save itself is unchanged, so signed JSON saves remain readable.
+const signaturePrefix = "ECS-HMAC-SHA256 "
+
+// SignatureError is returned when a save fails signature verification, e.g. because it has been edited by hand.
+type SignatureError struct {
+	Reason string
+}
+
+func (e *SignatureError) Error() string {
+	return "save signature is invalid: " + e.Reason
+}
+
+// SetSigningKey sets a key used to sign saves written by World.Save, World.SaveAsync and SaveManager. Once a key is
+// set, World.Load rejects saves which are unsigned or whose signature does not match with a *SignatureError.
+func (w *World) SetSigningKey(key []byte) {
+	w.signingKey = key
+}
+
+// SetSigningKey sets a key used to sign everything the encoder writes. See World.SetSigningKey.
+func (enc *Encoder) SetSigningKey(key []byte) {
+	enc.key = key
+}
+
+func isSigned(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(signaturePrefix))
+}
+
+func signature(payload []byte, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func writeSigned(out io.Writer, payload []byte, key []byte) error {
+	header := signaturePrefix + hex.EncodeToString(signature(payload, key)) + "\n"
+	if _, err := io.WriteString(out, header); err != nil {
+		return err
+	}
+	_, err := out.Write(payload)
+	return err
+}
+
+// verifySigned checks the signature of a save and returns the save without its signature. If no key is given, the
+// signature (if any) is removed without being checked.
+func verifySigned(data []byte, key []byte) ([]byte, error) {
+	if !isSigned(data) {
+		if key != nil {
+			return nil, &SignatureError{Reason: "save is not signed"}
+		}
+		return data, nil
+	}
+	end := bytes.IndexByte(data, '\n')
+	if end < 0 {
+		return nil, &SignatureError{Reason: "signature line is incomplete"}
+	}
+	payload := data[end+1:]
+	if key == nil {
+		return payload, nil
+	}
+	expected, err := hex.DecodeString(string(data[len(signaturePrefix):end]))
+	if err != nil {
+		return nil, &SignatureError{Reason: "signature is not valid hex"}
+	}
+	if !hmac.Equal(expected, signature(payload, key)) {
+		return nil, &SignatureError{Reason: "signature does not match"}
+	}
+	return payload, nil
+}
@@ -0,0 +1,100 @@
+package ecs
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSigningKey = []byte("not very secret")
+
+func TestSignedSavesCanBeLoaded(t *testing.T) {
+	for _, options := range saveOptions {
+		world := buildSaveWorld(3)
+		world.SetSigningKey(testSigningKey)
+
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, world.Save(buf, options))
+		assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte(signaturePrefix)))
+
+		loaded := NewWorld(0)
+		loaded.SetSigningKey(testSigningKey)
+		require.NoError(t, loaded.Load(buf))
+
+		divergence, err := CompareWorlds(world, loaded)
+		require.NoError(t, err)
+		assert.Nil(t, divergence)
+	}
+}
+
+func TestSignedSaveKeepsComponentEncoding(t *testing.T) {
+	world := buildSaveWorld(1)
+
+	unsigned := bytes.NewBuffer(nil)
+	require.NoError(t, world.Save(unsigned, SaveOptions{}))
+
+	world.SetSigningKey(testSigningKey)
+	signed := bytes.NewBuffer(nil)
+	require.NoError(t, world.Save(signed, SaveOptions{}))
+
+	assert.True(t, bytes.HasSuffix(signed.Bytes(), unsigned.Bytes()))
+}
+
+func TestTamperedSaveIsRejected(t *testing.T) {
+	world := buildSaveWorld(1)
+	world.SetSigningKey(testSigningKey)
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, world.Save(buf, SaveOptions{}))
+	tampered := bytes.Replace(buf.Bytes(), []byte(`"X":0`), []byte(`"X":9999`), 1)
+	require.NotEqual(t, buf.Bytes(), tampered)
+
+	loaded := NewWorld(0)
+	loaded.SetSigningKey(testSigningKey)
+	err := loaded.Load(bytes.NewReader(tampered))
+
+	var sigErr *SignatureError
+	require.True(t, errors.As(err, &sigErr))
+	assert.Len(t, loaded.GetEntities(), 0)
+}
+
+func TestUnsignedSaveIsRejectedWhenKeyIsSet(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, buildSaveWorld(1).Save(buf, SaveOptions{}))
+
+	loaded := NewWorld(0)
+	loaded.SetSigningKey(testSigningKey)
+	err := loaded.Load(buf)
+
+	var sigErr *SignatureError
+	assert.True(t, errors.As(err, &sigErr))
+}
+
+func TestSaveSignedWithDifferentKeyIsRejected(t *testing.T) {
+	world := buildSaveWorld(1)
+	world.SetSigningKey([]byte("another key"))
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, world.Save(buf, SaveOptions{Format: FormatBinary}))
+
+	loaded := NewWorld(0)
+	loaded.SetSigningKey(testSigningKey)
+	err := loaded.Load(buf)
+
+	var sigErr *SignatureError
+	assert.True(t, errors.As(err, &sigErr))
+}
+
+func TestSignedSavesWorkWithSaveManager(t *testing.T) {
+	manager := NewSaveManager(tempSaveDir(t), SaveOptions{Compress: true})
+	world := buildSaveWorld(2)
+	world.SetSigningKey(testSigningKey)
+	require.NoError(t, manager.Save(world, 0))
+
+	loaded := NewWorld(0)
+	loaded.SetSigningKey(testSigningKey)
+	require.NoError(t, manager.Load(loaded, 0))
+	assert.Len(t, loaded.GetEntities(), 2)
+}
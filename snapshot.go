@@ -0,0 +1,89 @@
+package ecs
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrNoHistory is returned by World.Undo when there is no earlier turn to return to.
+var ErrNoHistory = errors.New("no earlier turn has been captured")
+
+// Snapshot is an immutable copy of the state of a world, as saved by World.MarshalJSON.
+type Snapshot struct {
+	turn int64
+	data []byte
+}
+
+// Turn returns the turn the world was on when the snapshot was taken.
+func (s *Snapshot) Turn() int64 {
+	return s.turn
+}
+
+// MarshalJSON returns a copy of the saved world contained in the snapshot.
+func (s *Snapshot) MarshalJSON() ([]byte, error) {
+	data := make([]byte, len(s.data))
+	copy(data, s.data)
+	return data, nil
+}
+
+// Snapshot captures the current state of the world.
+func (w *World) Snapshot() (*Snapshot, error) {
+	data, err := json.Marshal(w)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{
+		turn: w.turn,
+		data: data,
+	}, nil
+}
+
+// Restore replaces the state of the world with a snapshot. The current entities are removed from their systems via
+// System.Remove, and fresh copies of the snapshot's entities are added via System.Add. A snapshot can be restored any
+// number of times.
+func (w *World) Restore(snapshot *Snapshot) error {
+	return json.Unmarshal(snapshot.data, w)
+}
+
+// SetHistorySize sets how many turns are automatically captured by UseTurn for use by Undo. The current state is
+// captured straight away. A size of zero disables the history.
+func (w *World) SetHistorySize(size int) error {
+	w.historySize = size
+	w.history = nil
+	if size <= 0 {
+		return nil
+	}
+	return w.captureHistory()
+}
+
+// History returns a copy of the list of captured turns, oldest first.
+func (w *World) History() []*Snapshot {
+	history := make([]*Snapshot, len(w.history))
+	copy(history, w.history)
+	return history
+}
+
+// Undo restores the world to the start of the previous captured turn.
+func (w *World) Undo() error {
+	if len(w.history) < 2 {
+		return ErrNoHistory
+	}
+	previous := w.history[len(w.history)-2]
+	if err := w.Restore(previous); err != nil {
+		return err
+	}
+	w.history = w.history[:len(w.history)-1]
+	return nil
+}
+
+func (w *World) captureHistory() error {
+	snapshot, err := w.Snapshot()
+	if err != nil {
+		return err
+	}
+	if len(w.history) >= w.historySize {
+		w.history = append(w.history[:0], w.history[len(w.history)-w.historySize+1:]...)
+	}
+	w.history = append(w.history, snapshot)
+	return nil
+}
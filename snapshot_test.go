@@ -0,0 +1,103 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotCanBeRestored(t *testing.T) {
+	world := NewWorld(1)
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+
+	e := NewEntity()
+	e.Add(&TestComponent{X: 1})
+	world.AddEntity(e)
+
+	snapshot, err := world.Snapshot()
+	require.NoError(t, err)
+
+	var testable *Testable
+	e.Component(testable).(Testable).TestComponent().X = 2
+	world.AddEntity(NewEntity())
+	world.UseTurn()
+
+	require.NoError(t, world.Restore(snapshot))
+
+	assert.Equal(t, int64(1), world.GetTurn())
+	require.Len(t, world.GetEntities(), 1)
+	restored := world.GetEntity(e.ID())
+	require.NotNil(t, restored)
+	assert.Equal(t, 1, restored.Component(testable).(Testable).TestComponent().X)
+
+	assert.Equal(t, []*Entity{e}, system.removedEntities)
+	assert.Equal(t, []*Entity{e, restored}, system.addedEntities)
+	assert.Equal(t, int64(1), snapshot.Turn())
+}
+
+func TestUndoRestoresPreviousTurn(t *testing.T) {
+	world := NewWorld(0)
+
+	e := NewEntity()
+	e.Add(&TestComponent{X: 0})
+	world.AddEntity(e)
+	world.SetPlayer(e)
+
+	require.NoError(t, world.SetHistorySize(3))
+
+	var testable *Testable
+	for i := 1; i <= 5; i++ {
+		world.Player().Component(testable).(Testable).TestComponent().X = i
+		world.UseTurn()
+	}
+	require.Len(t, world.History(), 3)
+	assert.Equal(t, int64(3), world.History()[0].Turn())
+
+	require.NoError(t, world.Undo())
+	assert.Equal(t, int64(4), world.GetTurn())
+	assert.Equal(t, 4, world.Player().Component(testable).(Testable).TestComponent().X)
+
+	require.NoError(t, world.Undo())
+	assert.Equal(t, int64(3), world.GetTurn())
+	assert.Equal(t, 3, world.Player().Component(testable).(Testable).TestComponent().X)
+
+	assert.Equal(t, ErrNoHistory, world.Undo())
+}
+
+func TestTurnsUsedBySystemsAreCapturedAtTheEndOfTheUpdate(t *testing.T) {
+	world := buildSaveWorld(1)
+	world.AddSystem(&TurnUsingSystem{}, false)
+	world.AddSystem(&IncrementingSystem{}, false)
+	require.NoError(t, world.SetHistorySize(5))
+	turn := world.GetTurn()
+
+	require.NoError(t, world.TryUpdate())
+	require.NoError(t, world.TryUpdate())
+	require.NoError(t, world.Undo())
+
+	// the world is as it was after the first update, not part way through the second
+	var testable *Testable
+	assert.Equal(t, turn+1, world.GetTurn())
+	assert.Equal(t, 1, world.GetEntities()[0].Component(testable).(Testable).TestComponent().X)
+}
+
+func TestSnapshotsCannotBeChangedByCallers(t *testing.T) {
+	world := NewWorld(0)
+	require.NoError(t, world.SetHistorySize(2))
+	snapshot, err := world.Snapshot()
+	require.NoError(t, err)
+
+	data, err := snapshot.MarshalJSON()
+	require.NoError(t, err)
+	data[0] = 'x'
+	again, err := snapshot.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, byte('{'), again[0])
+
+	world.UseTurn()
+	history := world.History()
+	world.UseTurn()
+	assert.Equal(t, int64(0), history[0].Turn())
+}
@@ -0,0 +1,375 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+// rawComponent is a component which has already been encoded by encodeComponent.
+type rawComponent struct {
+	name  string
+	data  []byte
+	codec bool
+}
+
+func encodeRawComponents(store *ComponentStore) ([]rawComponent, error) {
+	raw := make([]rawComponent, 0, len(store.components))
+	for _, c := range store.components {
+		data, codec, err := encodeComponent(c.Inner)
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, rawComponent{
+			name:  componentName(c.Inner),
+			data:  data,
+			codec: codec,
+		})
+	}
+	return raw, nil
+}
+
+func (c rawComponent) saved() (savedComponent, error) {
+	saved := savedComponent{
+		Type: c.name,
+		Data: c.data,
+	}
+	if c.codec {
+		saved.Encoding = encodingCodec
+		var err error
+		if saved.Data, err = json.Marshal(c.data); err != nil {
+			return saved, err
+		}
+	}
+	return saved, nil
+}
+
+type rawEntity struct {
+	id         uuid.UUID
+	disabled   bool
+	components []rawComponent
+}
+
+func encodeRawEntity(e *Entity) (rawEntity, error) {
+	components, err := encodeRawComponents(e.Store)
+	return rawEntity{
+		id:         e.ID(),
+		disabled:   e.Disabled,
+		components: components,
+	}, err
+}
+
+// worldSource is the state of a world to be encoded. Entities are requested one at a time, so that a live world can
+// be encoded without holding the whole encoded save in memory.
+type worldSource interface {
+	turn() int64
+	controllers() []savedController
+	componentNames() []string
+	resources() ([]rawComponent, error)
+	entityCount() int
+	entity(i int) (rawEntity, error)
+}
+
+// liveWorld encodes the entities of a world as they are requested.
+type liveWorld struct {
+	saved *savedWorld
+}
+
+func newLiveWorld(w *World) *liveWorld {
+	return &liveWorld{
+		saved: w.save(),
+	}
+}
+
+func (l *liveWorld) turn() int64 {
+	return l.saved.Turn
+}
+
+func (l *liveWorld) controllers() []savedController {
+	return l.saved.Controllers
+}
+
+func (l *liveWorld) componentNames() []string {
+	var names uniqueNames
+	stores := []*ComponentStore{l.saved.Resources}
+	for _, e := range l.saved.Entities {
+		stores = append(stores, e.Store)
+	}
+	for _, store := range stores {
+		for _, c := range store.components {
+			names.add(componentName(c.Inner))
+		}
+	}
+	return names.names
+}
+
+// uniqueNames collects component names in the order they are first seen.
+type uniqueNames struct {
+	names []string
+	seen  map[string]bool
+}
+
+func (u *uniqueNames) add(name string) {
+	if u.seen == nil {
+		u.seen = make(map[string]bool)
+	}
+	if !u.seen[name] {
+		u.seen[name] = true
+		u.names = append(u.names, name)
+	}
+}
+
+func (l *liveWorld) resources() ([]rawComponent, error) {
+	return encodeRawComponents(l.saved.Resources)
+}
+
+func (l *liveWorld) entityCount() int {
+	return len(l.saved.Entities)
+}
+
+func (l *liveWorld) entity(i int) (rawEntity, error) {
+	return encodeRawEntity(l.saved.Entities[i])
+}
+
+// frozenWorld is a fully encoded copy of a world which can be saved without access to the world itself. It is also
+// the form in which saves are read, before their components are loaded.
+type frozenWorld struct {
+	frozenTurn        int64
+	frozenControllers []savedController
+	frozenResources   []rawComponent
+	entities          []rawEntity
+}
+
+func freeze(w *World) (*frozenWorld, error) {
+	live := newLiveWorld(w)
+	frozen := &frozenWorld{
+		frozenTurn:        live.turn(),
+		frozenControllers: live.controllers(),
+		entities:          make([]rawEntity, 0, live.entityCount()),
+	}
+	var err error
+	if frozen.frozenResources, err = live.resources(); err != nil {
+		return nil, err
+	}
+	for i := 0; i < live.entityCount(); i++ {
+		e, err := live.entity(i)
+		if err != nil {
+			return nil, err
+		}
+		frozen.entities = append(frozen.entities, e)
+	}
+	return frozen, nil
+}
+
+func (f *frozenWorld) turn() int64 {
+	return f.frozenTurn
+}
+
+func (f *frozenWorld) controllers() []savedController {
+	return f.frozenControllers
+}
+
+func (f *frozenWorld) componentNames() []string {
+	var names uniqueNames
+	for _, c := range f.frozenResources {
+		names.add(c.name)
+	}
+	for _, e := range f.entities {
+		for _, c := range e.components {
+			names.add(c.name)
+		}
+	}
+	return names.names
+}
+
+func (f *frozenWorld) resources() ([]rawComponent, error) {
+	return f.frozenResources, nil
+}
+
+func (f *frozenWorld) entityCount() int {
+	return len(f.entities)
+}
+
+func (f *frozenWorld) entity(i int) (rawEntity, error) {
+	return f.entities[i], nil
+}
+
+// Encoder writes worlds to a stream one entity at a time, rather than building the entire save in memory first.
+type Encoder struct {
+	out     io.Writer
+	options SaveOptions
+	key     []byte
+}
+
+// NewEncoder creates an encoder which writes to out using the given options.
+func NewEncoder(out io.Writer, options SaveOptions) *Encoder {
+	return &Encoder{
+		out:     out,
+		options: options,
+	}
+}
+
+// Encode writes the world. It must not be called whilst the world is being updated.
+func (enc *Encoder) Encode(w *World) error {
+	return enc.encode(newLiveWorld(w))
+}
+
+func (enc *Encoder) encode(src worldSource) error {
+	if enc.key == nil {
+		return enc.encodeUnsigned(enc.out, src)
+	}
+	// the signature comes first, so signed saves must be buffered
+	buf := bytes.NewBuffer(nil)
+	if err := enc.encodeUnsigned(buf, src); err != nil {
+		return err
+	}
+	return writeSigned(enc.out, buf.Bytes(), enc.key)
+}
+
+func (enc *Encoder) encodeUnsigned(out io.Writer, src worldSource) error {
+	return writeSave(out, enc.options, func(out io.Writer) error {
+		switch enc.options.Format {
+		case FormatBinary:
+			return writeBinaryWorld(out, src)
+		case FormatYAML:
+			return writeYAMLWorld(out, src)
+		}
+		return writeJSONWorld(out, src)
+	})
+}
+
+// encoder creates an encoder which signs with the world's key, if it has one.
+func (w *World) encoder(out io.Writer, options SaveOptions) *Encoder {
+	enc := NewEncoder(out, options)
+	enc.SetSigningKey(w.signingKey)
+	return enc
+}
+
+// writeJSONWorld writes the same document as World.MarshalJSON, one entity at a time.
+func writeJSONWorld(out io.Writer, src worldSource) error {
+	w := &jsonWriter{out: out}
+	w.write(`{"turn":`)
+	w.value(src.turn())
+	if controllers := src.controllers(); len(controllers) > 0 {
+		w.write(`,"controllers":`)
+		w.value(controllers)
+	}
+	resources, err := src.resources()
+	if err != nil {
+		return err
+	}
+	w.write(`,"resources":`)
+	w.components(resources)
+	w.write(`,"entities":[`)
+	for i := 0; i < src.entityCount() && w.err == nil; i++ {
+		e, err := src.entity(i)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			w.write(",")
+		}
+		w.entity(e)
+	}
+	w.write("]}\n")
+	return w.err
+}
+
+// writeJSONEntity writes the same document as json.Marshal produces for an Entity.
+func writeJSONEntity(out io.Writer, e rawEntity) error {
+	w := &jsonWriter{out: out}
+	w.entity(e)
+	w.write("\n")
+	return w.err
+}
+
+type jsonWriter struct {
+	out io.Writer
+	err error
+}
+
+func (w *jsonWriter) write(s string) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = io.WriteString(w.out, s)
+}
+
+func (w *jsonWriter) value(v interface{}) {
+	if w.err != nil {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		w.err = err
+		return
+	}
+	_, w.err = w.out.Write(data)
+}
+
+func (w *jsonWriter) entity(e rawEntity) {
+	w.write(`{"uuid":`)
+	w.value(e.id)
+	if e.disabled {
+		w.write(`,"disabled":true`)
+	}
+	w.write(`,"components":`)
+	w.components(e.components)
+	w.write("}")
+}
+
+func (w *jsonWriter) components(components []rawComponent) {
+	w.write("[")
+	for i, c := range components {
+		if i > 0 {
+			w.write(",")
+		}
+		saved, err := c.saved()
+		if err != nil && w.err == nil {
+			w.err = err
+		}
+		w.value(saved)
+	}
+	w.write("]")
+}
+
+// SaveAsync saves the world to out on a background goroutine, so that the game loop is not blocked whilst the save
+// is written. The world is captured between updates: if SaveAsync is called by a system during an update, the capture
+// happens once the update has finished. Capturing encodes each component on the calling goroutine, as components may
+// be changed by the game as soon as it returns; only the assembly of the save in the chosen format, compression and
+// writing happen in the background. The returned channel receives the result of the save.
+func (w *World) SaveAsync(out io.Writer, options SaveOptions) <-chan error {
+	result := make(chan error, 1)
+	capture := func() {
+		frozen, err := freeze(w)
+		if err != nil {
+			result <- err
+			return
+		}
+		go func() {
+			result <- w.encoder(out, options).encode(frozen)
+		}()
+	}
+	w.afterUpdate(capture)
+	return result
+}
+
+// afterUpdate calls capture once the current update has finished, or straight away if the world is not updating.
+func (w *World) afterUpdate(capture func()) {
+	if w.updating {
+		w.pendingCaptures = append(w.pendingCaptures, capture)
+	} else {
+		capture()
+	}
+}
+
+// runPendingCaptures is called at the end of each update to capture any worlds requested during the update.
+func (w *World) runPendingCaptures() {
+	captures := w.pendingCaptures
+	w.pendingCaptures = nil
+	for _, capture := range captures {
+		capture()
+	}
+}
@@ -0,0 +1,133 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoderMatchesMarshalJSON(t *testing.T) {
+	world := buildSaveWorld(5)
+	world.AddResource(&GridComponent{Cells: []byte{1}})
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, NewEncoder(buf, SaveOptions{}).Encode(world))
+
+	marshalled, err := json.Marshal(world)
+	require.NoError(t, err)
+
+	var streamed, expected interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &streamed))
+	require.NoError(t, json.Unmarshal(marshalled, &expected))
+	assert.Equal(t, expected, streamed)
+}
+
+type SavingSystem struct {
+	TestSystem
+	out    io.Writer
+	result <-chan error
+}
+
+func (s *SavingSystem) Update(w *World, _ *Entity) {
+	s.result = w.SaveAsync(s.out, SaveOptions{Format: FormatBinary})
+}
+
+type IncrementingSystem struct {
+	TestSystem
+}
+
+func (s *IncrementingSystem) Update(_ *World, _ *Entity) {
+	var testable *Testable
+	for _, e := range s.addedEntities {
+		e.Component(testable).(Testable).TestComponent().X++
+	}
+}
+
+func TestSaveAsyncDoesNotBlockTheWorld(t *testing.T) {
+	world := buildSaveWorld(3)
+	incrementer := &IncrementingSystem{}
+	world.AddSystem(incrementer, false)
+
+	reader, writer := io.Pipe()
+	result := world.SaveAsync(writer, SaveOptions{})
+
+	// the save cannot complete until the pipe is read, but the world should carry on regardless
+	for i := 0; i < 10; i++ {
+		require.NoError(t, world.TryUpdate())
+	}
+
+	data := make(chan []byte)
+	go func() {
+		saved, _ := ioutil.ReadAll(reader)
+		data <- saved
+	}()
+	require.NoError(t, <-result)
+	require.NoError(t, writer.Close())
+
+	loaded := NewWorld(0)
+	require.NoError(t, loaded.Load(bytes.NewReader(<-data)))
+
+	var testable *Testable
+	for i, e := range world.GetEntities() {
+		assert.Equal(t, i+10, e.Component(testable).(Testable).TestComponent().X)
+		assert.Equal(t, i, loaded.GetEntity(e.ID()).Component(testable).(Testable).TestComponent().X)
+	}
+}
+
+func TestSaveAsyncDuringUpdateCapturesAtEndOfUpdate(t *testing.T) {
+	world := buildSaveWorld(1)
+
+	buf := bytes.NewBuffer(nil)
+	saver := &SavingSystem{out: buf}
+	world.AddSystem(saver, false)
+	world.AddSystem(&IncrementingSystem{}, false)
+
+	require.NoError(t, world.TryUpdate())
+	require.NotNil(t, saver.result)
+	require.NoError(t, <-saver.result)
+
+	loaded := NewWorld(0)
+	require.NoError(t, loaded.Load(buf))
+
+	var testable *Testable
+	assert.Equal(t, 1, loaded.GetEntities()[0].Component(testable).(Testable).TestComponent().X)
+}
+
+func TestSaveAsyncAfterAPanickingUpdateIsNotDeferred(t *testing.T) {
+	world := buildSaveWorld(1)
+	world.AddSystem(&PanickingSystem{err: errors.New("oops")}, false)
+	assert.Panics(t, func() { world.Update() })
+
+	select {
+	case err := <-world.SaveAsync(bytes.NewBuffer(nil), SaveOptions{}):
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("save was deferred to an update which is not running")
+	}
+}
+
+func TestSaveAsyncDuringRepeatableUpdateCapturesAtEndOfUpdate(t *testing.T) {
+	world := buildSaveWorld(1)
+
+	buf := bytes.NewBuffer(nil)
+	saver := &SavingSystem{out: buf}
+	world.AddSystem(saver, true)
+	world.AddSystem(&IncrementingSystem{}, true)
+
+	require.NoError(t, world.TryUpdateRepeatable())
+	require.NotNil(t, saver.result)
+	require.NoError(t, <-saver.result)
+
+	loaded := NewWorld(0)
+	require.NoError(t, loaded.Load(buf))
+
+	var testable *Testable
+	assert.Equal(t, 1, loaded.GetEntities()[0].Component(testable).(Testable).TestComponent().X)
+}
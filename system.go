@@ -6,3 +6,19 @@ type System interface {
 	Remove(entity *Entity)
 	RequiredTypes() []interface{}
 }
+
+// ContextSystem is a System which wants more than the legacy player parameter on each update. When a system
+// implements ContextSystem, UpdateWithContext is called in place of Update.
+type ContextSystem interface {
+	System
+	UpdateWithContext(ctx *UpdateContext)
+}
+
+// UpdateContext is passed to a ContextSystem on each update.
+type UpdateContext struct {
+	World *World
+	// Player is the same entity passed to System.Update. See World.Player.
+	Player *Entity
+	// Controllers are all controllers bound to the world, in the order they were bound.
+	Controllers []*Controller
+}
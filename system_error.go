@@ -0,0 +1,122 @@
+package ecs
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicPolicy controls what the world does when a system panics.
+type PanicPolicy int
+
+const (
+	// PanicPropagate lets system panics unwind through the world as normal. This is the default.
+	PanicPropagate PanicPolicy = iota
+	// PanicRecover recovers system panics and reports them as a *SystemPanicError.
+	PanicRecover
+	// PanicRecoverAndDisable recovers system panics as PanicRecover does, and also disables the offending system so
+	// it is no longer updated.
+	PanicRecoverAndDisable
+)
+
+// SystemPhase describes which part of a system was being called when it panicked.
+type SystemPhase string
+
+const (
+	PhaseAdd              SystemPhase = "add"
+	PhaseRemove           SystemPhase = "remove"
+	PhaseUpdate           SystemPhase = "update"
+	PhaseRepeatableUpdate SystemPhase = "repeatable update"
+)
+
+// SystemPanicError describes a recovered panic from inside a system.
+type SystemPanicError struct {
+	System System
+	Phase  SystemPhase
+	Turn   int64
+	// Entity is the entity being added/removed when the panic occurred. It is nil for updates.
+	Entity *Entity
+	// Value is the value passed to panic().
+	Value interface{}
+	// Stack is the stack trace of the goroutine at the point the panic was recovered.
+	Stack []byte
+}
+
+func (e *SystemPanicError) Error() string {
+	if e.Entity != nil {
+		return fmt.Sprintf("system %T panicked during %s of entity %s on turn %d: %v", e.System, e.Phase, e.Entity.ID(), e.Turn, e.Value)
+	}
+	return fmt.Sprintf("system %T panicked during %s on turn %d: %v", e.System, e.Phase, e.Turn, e.Value)
+}
+
+// Unwrap returns the panic value if it was an error.
+func (e *SystemPanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// SetPanicPolicy sets how panics from inside systems are handled. See PanicPolicy.
+func (w *World) SetPanicPolicy(policy PanicPolicy) {
+	w.panicPolicy = policy
+}
+
+// SetErrorHandler sets a function to receive errors such as recovered system panics. When a handler is set, these
+// errors are no longer returned from TryUpdate/TryRun, and no longer cause Update/Run to panic. Without a handler,
+// panics recovered from System.Add and System.Remove outside of an update, such as during AddEntity, are raised again
+// as a *SystemPanicError by the call which caused them.
+func (w *World) SetErrorHandler(handler func(err error)) {
+	w.errorHandler = handler
+}
+
+// invoke calls fn on behalf of the given registration, recovering any panic according to the world's panic policy.
+func (w *World) invoke(reg *systemRegistration, phase SystemPhase, entity *Entity, fn func()) {
+	if w.panicPolicy == PanicPropagate {
+		fn()
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if w.panicPolicy == PanicRecoverAndDisable {
+				reg.disabled = true
+			}
+			w.reportError(&SystemPanicError{
+				System: reg.system,
+				Phase:  phase,
+				Turn:   w.turn,
+				Entity: entity,
+				Value:  r,
+				Stack:  debug.Stack(),
+			})
+		}
+	}()
+	fn()
+}
+
+// reportError passes err to the error handler. Without one, errors are collected to be returned at the end of the
+// current update, or raised immediately if the world is not updating.
+func (w *World) reportError(err error) {
+	if w.errorHandler != nil {
+		w.errorHandler(err)
+		return
+	}
+	if !w.collectErrors {
+		panic(err)
+	}
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *World) takeError() error {
+	err := w.err
+	w.err = nil
+	return err
+}
+
+// must panics with err if it is not nil.
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
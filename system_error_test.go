@@ -0,0 +1,137 @@
+package ecs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type PanickingSystem struct {
+	TestSystem
+	err error
+}
+
+func (s *PanickingSystem) Update(w *World, p *Entity) {
+	s.TestSystem.Update(w, p)
+	panic(s.err)
+}
+
+func TestSystemPanicsPropagateByDefault(t *testing.T) {
+	world := NewWorld(0)
+	world.AddSystem(&PanickingSystem{err: errors.New("oops")}, false)
+
+	assert.Panics(t, func() {
+		world.Update()
+	})
+}
+
+func TestRecoveredSystemPanicIsReturnedFromRun(t *testing.T) {
+	world := NewWorld(7)
+	world.SetPanicPolicy(PanicRecover)
+
+	cause := errors.New("oops")
+	system := &PanickingSystem{err: cause}
+	world.AddSystem(system, false)
+
+	err := world.TryRun()
+	require.Error(t, err)
+
+	var panicErr *SystemPanicError
+	require.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, system, panicErr.System)
+	assert.Equal(t, PhaseUpdate, panicErr.Phase)
+	assert.Equal(t, int64(7), panicErr.Turn)
+	assert.True(t, errors.Is(err, cause))
+	assert.Contains(t, err.Error(), "*ecs.PanickingSystem")
+}
+
+func TestRecoveredSystemPanicIsPassedToErrorHandler(t *testing.T) {
+	world := NewWorld(0)
+	world.SetPanicPolicy(PanicRecover)
+
+	var handled []error
+	world.SetErrorHandler(func(err error) {
+		handled = append(handled, err)
+	})
+
+	system := &PanickingSystem{err: errors.New("oops")}
+	world.AddSystem(system, false)
+
+	require.NoError(t, world.TryUpdate())
+	require.NoError(t, world.TryUpdate())
+
+	assert.Len(t, handled, 2)
+	assert.Equal(t, 2, system.updateCount)
+}
+
+func TestPanickingSystemIsDisabledWhenConfigured(t *testing.T) {
+	world := NewWorld(0)
+	world.SetPanicPolicy(PanicRecoverAndDisable)
+
+	system := &PanickingSystem{err: errors.New("oops")}
+	world.AddSystem(system, false)
+	other := &TestSystem{}
+	world.AddSystem(other, false)
+
+	require.Error(t, world.TryUpdate())
+	require.NoError(t, world.TryUpdate())
+
+	assert.Equal(t, 1, system.updateCount)
+	assert.Equal(t, 2, other.updateCount)
+}
+
+type AddPanickingSystem struct {
+	TestSystem
+}
+
+func (s *AddPanickingSystem) Add(entity *Entity) {
+	panic("cannot add")
+}
+
+func TestRecoveredPanicsFromAddAreRaisedWhereTheyHappen(t *testing.T) {
+	world := NewWorld(0)
+	world.SetPanicPolicy(PanicRecover)
+	system := &AddPanickingSystem{}
+	world.AddSystem(system, false)
+
+	e := NewEntity()
+	e.Add(&TestComponent{})
+	defer func() {
+		panicErr, ok := recover().(*SystemPanicError)
+		require.True(t, ok)
+		assert.Equal(t, PhaseAdd, panicErr.Phase)
+		assert.Equal(t, e, panicErr.Entity)
+		// the error is not reported again by the next update
+		assert.NoError(t, world.TryUpdate())
+	}()
+	world.AddEntity(e)
+}
+
+func TestRecoveredPanicsFromAddArePassedToErrorHandler(t *testing.T) {
+	world := NewWorld(0)
+	world.SetPanicPolicy(PanicRecover)
+	var handled []error
+	world.SetErrorHandler(func(err error) {
+		handled = append(handled, err)
+	})
+	world.AddSystem(&AddPanickingSystem{}, false)
+
+	e := NewEntity()
+	e.Add(&TestComponent{})
+	world.AddEntity(e)
+	assert.Len(t, handled, 1)
+}
+
+func TestUpdatePanicsWithRecoveredErrorsWhenNoHandlerIsSet(t *testing.T) {
+	world := NewWorld(0)
+	world.SetPanicPolicy(PanicRecover)
+	world.AddSystem(&PanickingSystem{err: errors.New("oops")}, false)
+
+	defer func() {
+		_, ok := recover().(*SystemPanicError)
+		assert.True(t, ok)
+	}()
+	world.Update()
+}
@@ -0,0 +1,190 @@
+package ecs
+
+import (
+	"reflect"
+)
+
+type systemRegistration struct {
+	system     System
+	types      []reflect.Type
+	repeatable bool
+	disabled   bool
+	groups     []string
+	// members are the entities the system has been given via System.Add and not yet taken back via System.Remove
+	members []*Entity
+}
+
+// matches returns true if the entity is enabled and has a component implementing each of the types required by the
+// system. If exclude is non-nil, that component is ignored.
+func (reg *systemRegistration) matches(e *Entity, exclude interface{}) bool {
+	if e.Disabled {
+		return false
+	}
+	for _, t := range reg.types {
+		var found bool
+		for _, c := range e.Store.components {
+			if exclude != nil && c.Inner == exclude {
+				continue
+			}
+			found = reflect.TypeOf(c.Inner).Implements(t)
+			if found {
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (reg *systemRegistration) isMember(e *Entity) bool {
+	for _, m := range reg.members {
+		if m == e {
+			return true
+		}
+	}
+	return false
+}
+
+// addToSystem gives the entity to the system, or defers it until the system is next enabled.
+func (w *World) addToSystem(reg *systemRegistration, e *Entity) {
+	if !w.isActive(reg) {
+		return
+	}
+	w.modify(&reg.members, func(members []*Entity) []*Entity {
+		return insertEntity(members, e, w.ordering)
+	})
+	w.invoke(reg, PhaseAdd, e, func() { reg.system.Add(e) })
+}
+
+// removeFromSystem takes the entity back from the system, or defers it until the system is next enabled.
+func (w *World) removeFromSystem(reg *systemRegistration, e *Entity) {
+	if !w.isActive(reg) {
+		return
+	}
+	w.takeFromSystem(reg, e)
+}
+
+// takeFromSystem takes the entity back from the system whether or not the system is active.
+func (w *World) takeFromSystem(reg *systemRegistration, e *Entity) {
+	w.modify(&reg.members, func(members []*Entity) []*Entity {
+		return removeEntity(members, e, w.ordering)
+	})
+	w.invoke(reg, PhaseRemove, e, func() { reg.system.Remove(e) })
+}
+
+// resync brings the membership of an active system up to date with any changes made whilst it was inactive.
+func (w *World) resync(reg *systemRegistration) {
+	if !w.isActive(reg) {
+		return
+	}
+	members := make([]*Entity, len(reg.members))
+	copy(members, reg.members)
+	for _, e := range members {
+		if !w.hasEntity(e) || !reg.matches(e, nil) {
+			w.removeFromSystem(reg, e)
+		}
+	}
+	for _, e := range w.entities {
+		if !reg.isMember(e) && reg.matches(e, nil) {
+			w.addToSystem(reg, e)
+		}
+	}
+}
+
+func (w *World) hasEntity(e *Entity) bool {
+	for _, entity := range w.entities {
+		if entity == e {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *World) isActive(reg *systemRegistration) bool {
+	if reg.disabled {
+		return false
+	}
+	for _, group := range reg.groups {
+		if w.disabledGroups[group] {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *World) registration(system System) *systemRegistration {
+	for _, reg := range w.registrations {
+		if reg.system == system {
+			return reg
+		}
+	}
+	return nil
+}
+
+// RemoveSystem removes a system from the world. The system is given the chance to clean up via System.Remove for each
+// of its entities, even if it is disabled.
+func (w *World) RemoveSystem(system System) {
+	for i, reg := range w.registrations {
+		if reg.system != system {
+			continue
+		}
+		members := make([]*Entity, len(reg.members))
+		copy(members, reg.members)
+		for _, e := range members {
+			w.takeFromSystem(reg, e)
+		}
+		w.registrations = append(w.registrations[:i], w.registrations[i+1:]...)
+		return
+	}
+}
+
+// SetSystemEnabled enables or disables a system. Disabled systems are not updated, and are not told about entities
+// being added or removed. When a system is enabled again, System.Add and System.Remove are called for any changes
+// which happened whilst it was disabled.
+func (w *World) SetSystemEnabled(system System, enabled bool) {
+	reg := w.registration(system)
+	if reg == nil {
+		return
+	}
+	reg.disabled = !enabled
+	w.resync(reg)
+}
+
+// SystemEnabled returns true if the system is registered and neither it nor any of its groups are disabled.
+func (w *World) SystemEnabled(system System) bool {
+	reg := w.registration(system)
+	return reg != nil && w.isActive(reg)
+}
+
+// AddSystemToGroup adds a registered system to a named group, so that it can be enabled/disabled alongside the rest of
+// the group using SetGroupEnabled. A system can belong to many groups.
+func (w *World) AddSystemToGroup(system System, group string) {
+	reg := w.registration(system)
+	if reg == nil {
+		return
+	}
+	for _, g := range reg.groups {
+		if g == group {
+			return
+		}
+	}
+	reg.groups = append(reg.groups, group)
+}
+
+// SetGroupEnabled enables or disables every system in a group. A system only runs if it is enabled and none of its
+// groups are disabled.
+func (w *World) SetGroupEnabled(group string, enabled bool) {
+	if w.disabledGroups == nil {
+		w.disabledGroups = make(map[string]bool)
+	}
+	if enabled {
+		delete(w.disabledGroups, group)
+	} else {
+		w.disabledGroups[group] = true
+	}
+	for _, reg := range w.registrations {
+		w.resync(reg)
+	}
+}
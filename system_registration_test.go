@@ -0,0 +1,137 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemovedSystemIsNoLongerUpdated(t *testing.T) {
+	world := NewWorld(0)
+
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+
+	e := NewEntity()
+	e.Add(&TestComponent{})
+	world.AddEntity(e)
+
+	world.RemoveSystem(system)
+	require.NoError(t, world.TryUpdate())
+
+	assert.Equal(t, 0, system.updateCount)
+	require.Len(t, system.removedEntities, 1)
+	assert.Equal(t, e, system.removedEntities[0])
+}
+
+func TestRemovingADisabledSystemStillRemovesItsEntities(t *testing.T) {
+	world := NewWorld(0)
+
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+
+	e := NewEntity()
+	e.Add(&TestComponent{})
+	world.AddEntity(e)
+
+	world.SetSystemEnabled(system, false)
+	world.RemoveSystem(system)
+
+	assert.Equal(t, []*Entity{e}, system.removedEntities)
+	assert.False(t, world.SystemEnabled(system))
+	assert.Empty(t, world.SystemEntities(system))
+}
+
+func TestDisabledSystemIsNotUpdated(t *testing.T) {
+	world := NewWorld(0)
+
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+
+	world.SetSystemEnabled(system, false)
+	require.NoError(t, world.TryUpdate())
+	assert.False(t, world.SystemEnabled(system))
+	assert.Equal(t, 0, system.updateCount)
+
+	world.SetSystemEnabled(system, true)
+	require.NoError(t, world.TryUpdate())
+	assert.True(t, world.SystemEnabled(system))
+	assert.Equal(t, 1, system.updateCount)
+}
+
+func TestReenabledSystemIsResynchronised(t *testing.T) {
+	world := NewWorld(0)
+
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+
+	kept := NewEntity()
+	kept.Add(&TestComponent{})
+	world.AddEntity(kept)
+
+	removed := NewEntity()
+	removed.Add(&TestComponent{})
+	world.AddEntity(removed)
+
+	world.SetSystemEnabled(system, false)
+
+	added := NewEntity()
+	added.Add(&TestComponent{})
+	world.AddEntity(added)
+	world.RemoveEntity(removed)
+
+	assert.Len(t, system.addedEntities, 2)
+	assert.Len(t, system.removedEntities, 0)
+
+	world.SetSystemEnabled(system, true)
+
+	assert.Equal(t, []*Entity{kept, removed, added}, system.addedEntities)
+	assert.Equal(t, []*Entity{removed}, system.removedEntities)
+}
+
+func TestAddedThenRemovedWhilstDisabledIsNotSeenBySystem(t *testing.T) {
+	world := NewWorld(0)
+
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+	world.SetSystemEnabled(system, false)
+
+	e := NewEntity()
+	e.Add(&TestComponent{})
+	world.AddEntity(e)
+	world.RemoveEntity(e)
+
+	world.SetSystemEnabled(system, true)
+
+	assert.Len(t, system.addedEntities, 0)
+	assert.Len(t, system.removedEntities, 0)
+}
+
+func TestSystemGroupsAreToggledTogether(t *testing.T) {
+	world := NewWorld(0)
+
+	a := &TestSystem{}
+	b := &TestSystem{}
+	c := &TestSystem{}
+	world.AddSystem(a, false)
+	world.AddSystem(b, false)
+	world.AddSystem(c, false)
+	world.AddSystemToGroup(a, "gameplay")
+	world.AddSystemToGroup(b, "gameplay")
+
+	world.SetGroupEnabled("gameplay", false)
+	require.NoError(t, world.TryUpdate())
+
+	assert.Equal(t, 0, a.updateCount)
+	assert.Equal(t, 0, b.updateCount)
+	assert.Equal(t, 1, c.updateCount)
+
+	world.SetSystemEnabled(b, false)
+	world.SetGroupEnabled("gameplay", true)
+	require.NoError(t, world.TryUpdate())
+
+	assert.Equal(t, 1, a.updateCount)
+	assert.Equal(t, 0, b.updateCount)
+	assert.Equal(t, 2, c.updateCount)
+}
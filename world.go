@@ -1,37 +1,81 @@
 package ecs
 
 import (
+	"fmt"
 	"reflect"
 
 	"github.com/google/uuid"
 )
 
 type World struct {
-	registrations []systemRegistration
-	done          bool
-	turn          int64
-	entities      []*Entity
-	player        *Entity
-}
-
-type systemRegistration struct {
-	system     System
-	types      []reflect.Type
-	repeatable bool
+	registrations  []*systemRegistration
+	done           bool
+	turn           int64
+	entities       []*Entity
+	controllers    []*Controller
+	panicPolicy    PanicPolicy
+	errorHandler   func(err error)
+	err            error
+	disabledGroups map[string]bool
+	commands       []Command
+	recorder       *Recorder
+	resources      *ComponentStore
+	ordering       Ordering
+	history        []*Snapshot
+	historySize    int
+	// updating is true whilst systems are being updated, by Update or UpdateRepeatable
+	updating        bool
+	pendingCaptures []func()
+	turnHandlers    []func(turn int64)
+	signingKey      []byte
+	strictLoading   bool
+	// collectErrors is true whilst errors are being collected to be returned from TryUpdate or TryUpdateRepeatable
+	collectErrors bool
+	concurrency   worldSync
+	// simulating is the name of the inactive level being simulated, if any
+	simulating string
+	registry   *Registry
+	// sequence is the number of entities which have been added to the world
+	sequence uint64
 }
 
 func NewWorld(turn int64) *World {
 	return &World{
-		turn: turn,
+		turn:      turn,
+		resources: &ComponentStore{},
 	}
 }
 
+// UseTurn advances the world to the next turn, capturing it for Undo if a history size has been set, and then calls
+// any functions registered with OnTurn. If UseTurn is called by a system, the turn is captured once the update has
+// finished, so that Undo always returns to the state between updates.
 func (w *World) UseTurn() {
 	w.turn++
+	if w.historySize > 0 {
+		w.afterUpdate(func() {
+			// the turn may have been used more than once during the update, but only needs capturing once
+			if len(w.history) > 0 && w.history[len(w.history)-1].turn == w.turn {
+				return
+			}
+			if err := w.captureHistory(); err != nil {
+				w.reportError(err)
+			}
+		})
+	}
+	for _, handler := range w.turnHandlers {
+		handler(w.turn)
+	}
 }
 
+// OnTurn registers a function to be called with the new turn each time UseTurn is called.
+func (w *World) OnTurn(handler func(turn int64)) {
+	w.turnHandlers = append(w.turnHandlers, handler)
+}
+
+// SetPlayer binds the given entity to the PlayerController controller. For games with more than one controllable
+// entity, see BindController.
 func (w *World) SetPlayer(p *Entity) {
-	w.player = p
+	w.BindController(PlayerController, p, nil)
 }
 
 func (w *World) GetTurn() int64 {
@@ -46,7 +90,13 @@ func (w *World) Done() bool {
 	return w.done
 }
 
+// GetEntity returns the entity with the given ID, or nil if there is no such entity. In concurrent mode, the components
+// of the entity must only be read within View (see SetConcurrent).
 func (w *World) GetEntity(id uuid.UUID) *Entity {
+	if w.concurrency.enabled {
+		w.concurrency.entities.RLock()
+		defer w.concurrency.entities.RUnlock()
+	}
 	for _, entity := range w.entities {
 		if entity.ID() == id {
 			return entity
@@ -63,170 +113,299 @@ func (w *World) AddSystem(system System, repeatable bool) {
 		refTypes = append(refTypes, reflect.TypeOf(t).Elem())
 	}
 
-	reg := systemRegistration{
+	reg := &systemRegistration{
 		system:     system,
 		types:      refTypes,
 		repeatable: repeatable,
 	}
 
 	for _, e := range w.entities {
-		match := true
-		for _, t := range reg.types {
-			var found bool
-			for _, c := range e.Store.components {
-				found = reflect.TypeOf(c.Inner).Implements(t)
-				if found {
-					break
-				}
-			}
-			match = match && found
-			if !match {
-				break
-			}
-		}
-		if match {
-			reg.system.Add(e)
+		if reg.matches(e, nil) {
+			w.addToSystem(reg, e)
 		}
 	}
 
 	w.registrations = append(w.registrations, reg)
 }
 
+// Run updates the world until it is closed. If an error which was not passed to an error handler occurs, such as a
+// recovered system panic (see SetPanicPolicy), Run panics with it. See TryRun.
 func (w *World) Run() {
-	w.UpdateRepeatable()
+	must(w.TryRun())
+}
+
+// TryRun updates the world until it is closed, as Run does. If an error which was not passed to an error handler
+// occurs, TryRun stops and returns it.
+func (w *World) TryRun() error {
+	if err := w.TryUpdateRepeatable(); err != nil {
+		return err
+	}
 	for {
-		w.Update()
+		if err := w.TryUpdate(); err != nil {
+			return err
+		}
 		if w.Done() {
 			break
 		}
 	}
+	return nil
 }
 
+// Update applies any queued commands and then runs every enabled system once, followed by any inactive levels which
+// are due to be simulated (see SetLevelSimulation). If an error which was not passed to an error handler occurs, such
+// as a recovered system panic or a failed command, Update panics with the first one once the update has finished. See
+// TryUpdate.
 func (w *World) Update() {
+	must(w.TryUpdate())
+}
+
+// TryUpdate updates the world as Update does, returning the first error which was not passed to an error handler, if
+// any.
+func (w *World) TryUpdate() error {
+	var err error
+	w.exclusively(func() {
+		w.collectErrors = true
+		defer func() { w.collectErrors = false }()
+		w.updating = true
+		defer func() { w.updating = false }()
+		turn := w.turn
+		w.applySubmitted()
+		commands := w.applyCommands()
+		if w.recorder != nil {
+			w.recorder.record(turn, commands)
+		}
+		w.updateSystems()
+		w.simulateLevels()
+		w.updating = false
+		w.runPendingCaptures()
+		err = w.takeError()
+	})
+	return err
+}
+
+// updateSystems runs every enabled system once.
+func (w *World) updateSystems() {
 	for _, reg := range w.registrations {
-		reg.system.Update(w, w.player)
+		if !w.isActive(reg) {
+			continue
+		}
+		w.invoke(reg, PhaseUpdate, nil, func() { w.updateSystem(reg) })
+		w.applyDeferred(false)
 	}
 }
 
+// UpdateRepeatable runs every enabled repeatable system once. Errors are handled as they are by Update.
 func (w *World) UpdateRepeatable() {
-	for _, reg := range w.registrations {
-		if reg.repeatable {
-			reg.system.Update(w, w.player)
+	must(w.TryUpdateRepeatable())
+}
+
+// TryUpdateRepeatable runs every enabled repeatable system once, returning the first error which was not passed to an
+// error handler, if any.
+func (w *World) TryUpdateRepeatable() error {
+	var err error
+	w.exclusively(func() {
+		w.collectErrors = true
+		defer func() { w.collectErrors = false }()
+		w.updating = true
+		defer func() { w.updating = false }()
+		for _, reg := range w.registrations {
+			if reg.repeatable && w.isActive(reg) {
+				w.invoke(reg, PhaseRepeatableUpdate, nil, func() { w.updateSystem(reg) })
+				w.applyDeferred(false)
+			}
 		}
-	}
+		w.updating = false
+		w.runPendingCaptures()
+		err = w.takeError()
+	})
+	return err
 }
 
 func (w *World) AddEntity(e *Entity) {
-	w.entities = append(w.entities, e)
-	for _, reg := range w.registrations {
-		match := true
-		for _, t := range reg.types {
-			var found bool
-			for _, c := range e.Store.components {
-				found = reflect.TypeOf(c.Inner).Implements(t)
-				if found {
-					break
-				}
-			}
-			match = match && found
-			if !match {
-				break
+	w.synchronise(func() {
+		e.Store.ordering = w.ordering
+		w.sequence++
+		e.sequence = w.sequence
+		w.modify(&w.entities, func(entities []*Entity) []*Entity {
+			return insertEntity(entities, e, w.ordering)
+		})
+		for _, reg := range w.registrations {
+			if reg.matches(e, nil) {
+				w.addToSystem(reg, e)
 			}
 		}
-		if match {
-			reg.system.Add(e)
-		}
-	}
+	})
 }
 
 func (w *World) RemoveEntity(entity *Entity) {
+	w.synchronise(func() { w.removeEntity(entity) })
+}
 
-	for i, e := range w.entities {
-		if e == entity {
-			w.entities[i] = w.entities[len(w.entities)-1]
-			w.entities = w.entities[:len(w.entities)-1]
-			break
+func (w *World) removeEntity(entity *Entity) {
+
+	w.modify(&w.entities, func(entities []*Entity) []*Entity {
+		return removeEntity(entities, entity, w.ordering)
+	})
+
+	for _, reg := range w.registrations {
+		if reg.isMember(entity) {
+			w.removeFromSystem(reg, entity)
 		}
 	}
+}
 
-	for _, reg := range w.registrations {
-		reg.system.Remove(entity)
+// TransferEntity moves an entity from the world to dst, such as from an overworld to an instanced encounter. The entity
+// itself is moved rather than copied, so its UUID is kept and any references to it stay valid. It is removed from the
+// world's systems via System.Remove and added to the matching systems of dst via System.Add, and any controllers bound
+// to it are moved to dst. An error is returned, and nothing is moved, if the entity is not in the world, if dst already
+// holds an entity with the same UUID or a controller with the same name as one bound to the entity, or if any of its
+// components are not registered with dst (see SetRegistry).
+//
+// In concurrent mode, a transfer made whilst the world is updating, such as by one of its systems, is deferred like
+// other structural changes (see SetConcurrent). The checks are made when TransferEntity is called, and the entity and
+// its controllers are then moved together once the change is applied, so the entity is never in both worlds at once.
+func (w *World) TransferEntity(e *Entity, dst *World) error {
+	if w.GetEntity(e.ID()) != e {
+		return fmt.Errorf("cannot transfer entity %s: it is not in the world", e.ID())
+	}
+	if dst == w {
+		return nil
 	}
+	if dst.GetEntity(e.ID()) != nil {
+		return fmt.Errorf("cannot transfer entity %s: the destination world already has an entity with its ID", e.ID())
+	}
+	registry := dst.Registry()
+	for _, c := range e.Store.components {
+		if !registry.Registered(c.Inner) {
+			return fmt.Errorf("cannot transfer entity %s: component %s is not registered with the destination world",
+				e.ID(), componentName(c.Inner))
+		}
+	}
+	var controllers []*Controller
+	for _, c := range w.controllers {
+		if c.Entity != e {
+			continue
+		}
+		if dst.Controller(c.Name) != nil {
+			return fmt.Errorf("cannot transfer entity %s: the destination world already has a controller named '%s'",
+				e.ID(), c.Name)
+		}
+		controllers = append(controllers, c)
+	}
+
+	w.synchronise(func() {
+		w.removeEntity(e)
+		for _, c := range controllers {
+			w.UnbindController(c.Name)
+			dst.BindController(c.Name, e, c.Source)
+		}
+		dst.AddEntity(e)
+	})
+	return nil
 }
 
 func (w *World) ClearEntities() {
-	tmp := make([]*Entity, len(w.entities))
-	copy(tmp, w.entities)
-	for _, entity := range tmp {
-		w.RemoveEntity(entity)
-	}
+	w.synchronise(func() {
+		tmp := make([]*Entity, len(w.entities))
+		copy(tmp, w.entities)
+		for _, entity := range tmp {
+			w.removeEntity(entity)
+		}
+	})
 }
 
 // AddComponentToEntity adds a given component to an entity. The component (c) must always be a struct pointer.
 // If this change makes the entity a match for any previously uninvolved systems, it is added to those systems.
 func (w *World) AddComponentToEntity(c interface{}, e *Entity) {
+	w.synchronise(func() {
 
-	e.Add(c)
+		e.Add(c)
 
-	for _, reg := range w.registrations {
-		match := true
-		newTypeFound := false
-
-		for _, t := range reg.types {
-			var found bool
-
-			for _, c := range e.Store.components {
-				found = reflect.TypeOf(c.Inner).Implements(t)
-				if found {
-					break
-				}
-			}
-			match = match && found
-			if !match {
-				break
-			}
-			if reflect.TypeOf(c).Implements(t) {
-				newTypeFound = true
+		for _, reg := range w.registrations {
+			if !reg.isMember(e) && reg.matches(e, nil) {
+				w.addToSystem(reg, e)
 			}
 		}
-		if match && newTypeFound {
-			reg.system.Add(e)
+	})
+}
+
+// GetEntities returns the enabled entities in the world, in the order guaranteed by the world's Ordering. The list is
+// copied, so that it does not change as the world does, but the entities are not: in concurrent mode, their components
+// must only be read within View (see SetConcurrent).
+func (w *World) GetEntities() []*Entity {
+	return w.filterEntities(false)
+}
+
+// DisabledEntities returns the entities in the world which have been disabled with SetEntityEnabled.
+func (w *World) DisabledEntities() []*Entity {
+	return w.filterEntities(true)
+}
+
+// filterEntities returns a copy of the enabled entities in the world, or the disabled ones if disabled is true. In
+// concurrent mode, the flags are read whilst holding the lock on the entity list, under which setDisabled writes them.
+func (w *World) filterEntities(disabled bool) []*Entity {
+	if w.concurrency.enabled {
+		w.concurrency.entities.RLock()
+		defer w.concurrency.entities.RUnlock()
+	}
+	filtered := make([]*Entity, 0, len(w.entities))
+	for _, e := range w.entities {
+		if e.Disabled == disabled {
+			filtered = append(filtered, e)
 		}
 	}
+	return filtered
 }
 
-func (w *World) GetEntities() []*Entity {
-	return w.entities
+// setDisabled sets the disabled flag of an entity whilst no other goroutine is reading it with GetEntities.
+func (w *World) setDisabled(e *Entity, disabled bool) {
+	w.modify(&w.entities, func(entities []*Entity) []*Entity {
+		e.Disabled = disabled
+		return entities
+	})
+}
+
+// SetEntityEnabled enables or disables an entity in the world. Disabled entities stay in the world, and can still be
+// found with GetEntity and saved, but are hidden from systems and from GetEntities. Disabling an entity removes it
+// from its systems via System.Remove, and enabling it adds it to the matching systems via System.Add.
+func (w *World) SetEntityEnabled(e *Entity, enabled bool) {
+	w.synchronise(func() {
+		if e.Disabled == !enabled {
+			return
+		}
+		if !enabled {
+			w.setDisabled(e, true)
+			for _, reg := range w.registrations {
+				if reg.isMember(e) {
+					w.removeFromSystem(reg, e)
+				}
+			}
+			return
+		}
+		w.setDisabled(e, false)
+		if !w.hasEntity(e) {
+			return
+		}
+		for _, reg := range w.registrations {
+			if !reg.isMember(e) && reg.matches(e, nil) {
+				w.addToSystem(reg, e)
+			}
+		}
+	})
 }
 
 // RemoveComponentFromEntity removes a given component from an entity. The component must always be a struct pointer.
 // If this change makes the entity a non-match for any previously matched systems, it is removed from those systems.
 func (w *World) RemoveComponentFromEntity(c interface{}, e *Entity) {
+	w.synchronise(func() {
 
-	for _, reg := range w.registrations {
-		match := true
-		newTypeFound := false
-		for _, t := range reg.types {
-			var found bool
-			for _, c := range e.Store.components {
-				found = reflect.TypeOf(c.Inner).Implements(t)
-				if found {
-					break
-				}
-			}
-			match = match && found
-			if !match {
-				break
-			}
-			if reflect.TypeOf(c).Implements(t) {
-				newTypeFound = true
+		for _, reg := range w.registrations {
+			if reg.isMember(e) && !reg.matches(e, c) {
+				w.removeFromSystem(reg, e)
 			}
 		}
-		if match && newTypeFound {
-			reg.system.Remove(e)
-		}
-	}
 
-	// we must remove after the above checks so the implements() checks still pass
-	e.Remove(c)
+		// we must remove after the above checks so systems can still access the component during removal
+		e.Remove(c)
+	})
 }
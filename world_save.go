@@ -0,0 +1,77 @@
+package ecs
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+type savedWorld struct {
+	Turn        int64             `json:"turn" yaml:"turn"`
+	Entities    []*Entity         `json:"entities" yaml:"entities"`
+	Controllers []savedController `json:"controllers,omitempty" yaml:"controllers,omitempty"`
+	Resources   *ComponentStore   `json:"resources,omitempty" yaml:"resources,omitempty"`
+}
+
+type savedController struct {
+	Name   string    `json:"name" yaml:"name"`
+	Entity uuid.UUID `json:"entity" yaml:"entity"`
+}
+
+// MarshalJSON encodes the turn, entities, resources and controller bindings of the world. Systems are not saved.
+func (w *World) MarshalJSON() ([]byte, error) {
+	return json.Marshal(w.save())
+}
+
+// UnmarshalJSON replaces the state of the world with a previously saved one. Existing entities are removed from the
+// world and the loaded entities are added, so registered systems are kept up to date via System.Remove/System.Add.
+func (w *World) UnmarshalJSON(data []byte) error {
+	doc, err := readJSONDocument(data, w.strictLoading)
+	if err != nil {
+		return err
+	}
+	return w.loadDocument(doc)
+}
+
+func (w *World) save() *savedWorld {
+	saved := &savedWorld{
+		Turn:      w.turn,
+		Entities:  w.entities,
+		Resources: w.resources,
+	}
+	if saved.Entities == nil {
+		saved.Entities = []*Entity{}
+	}
+	for _, c := range w.controllers {
+		if c.Entity == nil {
+			continue
+		}
+		saved.Controllers = append(saved.Controllers, savedController{
+			Name:   c.Name,
+			Entity: c.Entity.ID(),
+		})
+	}
+	return saved
+}
+
+func (w *World) load(saved *savedWorld) {
+	w.ClearEntities()
+	w.turn = saved.Turn
+	if saved.Resources != nil {
+		saved.Resources.ordering = w.ordering
+		w.resources = saved.Resources
+	}
+	for _, e := range saved.Entities {
+		w.AddEntity(e)
+	}
+	for _, c := range w.controllers {
+		c.Entity = nil
+	}
+	for _, c := range saved.Controllers {
+		var source interface{}
+		if existing := w.Controller(c.Name); existing != nil {
+			source = existing.Source
+		}
+		w.BindController(c.Name, w.GetEntity(c.Entity), source)
+	}
+}
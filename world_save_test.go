@@ -0,0 +1,51 @@
+package ecs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldSerialisation(t *testing.T) {
+	world := NewWorld(42)
+
+	e := NewEntity()
+	e.Add(&TestComponent{X: 7})
+	world.AddEntity(e)
+	world.SetPlayer(e)
+
+	data, err := json.Marshal(world)
+	require.NoError(t, err)
+
+	loaded := NewWorld(0)
+	system := &TestSystem{}
+	loaded.AddSystem(system, false)
+	require.NoError(t, json.Unmarshal(data, loaded))
+
+	assert.Equal(t, int64(42), loaded.GetTurn())
+	require.Len(t, loaded.GetEntities(), 1)
+
+	le := loaded.GetEntity(e.ID())
+	require.NotNil(t, le)
+	assert.Equal(t, e, le)
+	assert.Equal(t, le, loaded.Player())
+	assert.Equal(t, []*Entity{le}, system.addedEntities)
+}
+
+func TestLoadingWorldRemovesExistingEntitiesFromSystems(t *testing.T) {
+	world := NewWorld(0)
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+
+	existing := NewEntity()
+	existing.Add(&TestComponent{})
+	world.AddEntity(existing)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"turn":3,"entities":[]}`), world))
+
+	assert.Equal(t, int64(3), world.GetTurn())
+	assert.Len(t, world.GetEntities(), 0)
+	assert.Equal(t, []*Entity{existing}, system.removedEntities)
+}
@@ -124,3 +124,148 @@ func TestComponentsAreRemovedFromEntitiesAndEntitiesFromRelevantSystems(t *testi
 	require.Len(t, system.removedEntities, 1)
 	assert.Equal(t, e, system.removedEntities[0])
 }
+
+func TestDisabledEntitiesAreHiddenFromSystemsAndQueries(t *testing.T) {
+	world := NewWorld(0)
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+
+	e := NewEntity()
+	e.Add(&TestComponent{})
+	world.AddEntity(e)
+	other := NewEntity()
+	world.AddEntity(other)
+
+	world.SetEntityEnabled(e, false)
+	assert.Equal(t, []*Entity{e}, system.removedEntities)
+	assert.Empty(t, world.SystemEntities(system))
+	assert.Equal(t, []*Entity{other}, world.GetEntities())
+	assert.Equal(t, []*Entity{e}, world.DisabledEntities())
+	assert.Equal(t, e, world.GetEntity(e.ID()))
+
+	// changes whilst disabled do not reach systems
+	world.AddComponentToEntity(&TestComponent{}, other)
+	world.AddComponentToEntity(&TestComponent{}, e)
+	world.AddSystem(&TestSystem{}, false)
+	assert.Equal(t, []*Entity{other}, world.SystemEntities(system))
+
+	world.SetEntityEnabled(e, true)
+	assert.Equal(t, []*Entity{e, other, e}, system.addedEntities)
+	assert.Len(t, world.SystemEntities(system), 2)
+	assert.Len(t, world.GetEntities(), 2)
+	assert.Empty(t, world.DisabledEntities())
+}
+
+func TestDisabledEntitiesAddedToWorldAreNotGivenToSystems(t *testing.T) {
+	world := NewWorld(0)
+	system := &TestSystem{}
+	world.AddSystem(system, false)
+
+	e := NewEntity()
+	e.Add(&TestComponent{})
+	e.Disabled = true
+	world.AddEntity(e)
+	assert.Empty(t, system.addedEntities)
+
+	world.SetEntityEnabled(e, true)
+	assert.Equal(t, []*Entity{e}, system.addedEntities)
+
+	world.SetEntityEnabled(e, true)
+	assert.Len(t, system.addedEntities, 1)
+}
+
+func TestEntitiesCanBeTransferredBetweenWorlds(t *testing.T) {
+	overworld := NewWorld(0)
+	overworldSystem := &TestSystem{}
+	overworld.AddSystem(overworldSystem, false)
+	encounter := NewWorld(0)
+	encounterSystem := &TestSystem{}
+	encounter.AddSystem(encounterSystem, false)
+
+	e := NewEntity()
+	e.Add(&TestComponent{X: 3})
+	overworld.AddEntity(e)
+	overworld.SetPlayer(e)
+	other := NewEntity()
+	overworld.AddEntity(other)
+
+	require.NoError(t, overworld.TransferEntity(e, encounter))
+	assert.Equal(t, []*Entity{e}, overworldSystem.removedEntities)
+	assert.Equal(t, []*Entity{e}, encounterSystem.addedEntities)
+	assert.Equal(t, []*Entity{other}, overworld.GetEntities())
+	assert.Equal(t, e, encounter.GetEntity(e.ID()))
+	assert.Equal(t, 3, e.Component(IsTestable).(Testable).TestComponent().X)
+	assert.Nil(t, overworld.Player())
+	assert.Equal(t, e, encounter.Player())
+
+	require.NoError(t, encounter.TransferEntity(e, overworld))
+	assert.Equal(t, e, overworld.GetEntity(e.ID()))
+	assert.Empty(t, encounter.GetEntities())
+}
+
+func TestTransferringAnEntityIsRejectedIfTheDestinationCannotHoldIt(t *testing.T) {
+	world := NewWorld(0)
+	e := NewEntity()
+	e.Add(&TestComponent{})
+	assert.Error(t, world.TransferEntity(e, NewWorld(0)))
+
+	world.AddEntity(e)
+	dst := NewWorld(0)
+	dst.SetRegistry(NewRegistry())
+	assert.Error(t, world.TransferEntity(e, dst))
+
+	dst = NewWorld(0)
+	clone := NewEntity()
+	clone.UUID = e.UUID
+	dst.AddEntity(clone)
+	assert.Error(t, world.TransferEntity(e, dst))
+
+	world.SetPlayer(e)
+	dst = NewWorld(0)
+	player := NewEntity()
+	dst.AddEntity(player)
+	dst.SetPlayer(player)
+	assert.Error(t, world.TransferEntity(e, dst))
+	assert.Equal(t, player, dst.Player())
+	assert.Equal(t, e, world.Player())
+
+	assert.Equal(t, []*Entity{e}, world.GetEntities())
+	assert.NoError(t, world.TransferEntity(e, world))
+}
+
+// TransferringSystem transfers the player to another world, recording whether each world holds it afterwards.
+type TransferringSystem struct {
+	TestSystem
+	dst     *World
+	holders []bool
+}
+
+func (s *TransferringSystem) Update(w *World, p *Entity) {
+	s.TestSystem.Update(w, p)
+	if p == nil {
+		return
+	}
+	if err := w.TransferEntity(p, s.dst); err != nil {
+		panic(err)
+	}
+	s.holders = append(s.holders, w.GetEntity(p.ID()) != nil, s.dst.GetEntity(p.ID()) != nil)
+}
+
+func TestTransfersBySystemsAreDeferredInConcurrentMode(t *testing.T) {
+	overworld := NewWorld(0)
+	overworld.SetConcurrent(true)
+	encounter := NewWorld(0)
+	system := &TransferringSystem{dst: encounter}
+	overworld.AddSystem(system, false)
+	e := NewEntity()
+	overworld.AddEntity(e)
+	overworld.SetPlayer(e)
+
+	require.NoError(t, overworld.TryUpdate())
+	// the entity stays in the source world until the system returns
+	assert.Equal(t, []bool{true, false}, system.holders)
+	assert.Empty(t, overworld.GetEntities())
+	assert.Equal(t, []*Entity{e}, encounter.GetEntities())
+	assert.Nil(t, overworld.Player())
+	assert.Equal(t, e, encounter.Player())
+}
@@ -0,0 +1,316 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// codecTag marks a YAML component whose value is the base64 encoded output of its codec.
+const codecTag = "!codec"
+
+// MarshalYAML writes the components as a list of single entry maps keyed by component name, e.g. an entity's
+// components are written as
+//
+//	components:
+//	  - Position:
+//	      x: 1
+//	      y: 2
+//	  - Health:
+//	      hp: 10
+//
+// Component fields are named exactly as they are in JSON. Components with a codec are written as base64 strings
+// tagged !codec.
+func (s *ComponentStore) MarshalYAML() (interface{}, error) {
+	raw, err := encodeRawComponents(s)
+	if err != nil {
+		return nil, err
+	}
+	return componentsToYAML(raw)
+}
+
+// UnmarshalYAML reads components written by MarshalYAML. Component types must be registered with RegisterComponent.
+func (s *ComponentStore) UnmarshalYAML(node *yaml.Node) error {
+	var comps []savedComponent
+	if err := node.Decode(&comps); err != nil {
+		return err
+	}
+	return s.load(comps, false)
+}
+
+// MarshalYAML encodes the world in the same way as MarshalJSON, using the YAML component layout.
+func (w *World) MarshalYAML() (interface{}, error) {
+	return w.save(), nil
+}
+
+// UnmarshalYAML replaces the state of the world with a previously saved one, in the same way as UnmarshalJSON.
+func (w *World) UnmarshalYAML(node *yaml.Node) error {
+	// yaml.Node.Decode cannot reject unknown fields, so the document is encoded again for readYAMLDocument
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	doc, err := readYAMLDocument(data, w.strictLoading)
+	if err != nil {
+		return err
+	}
+	return w.loadDocument(doc)
+}
+
+// writeYAMLWorld writes a document equivalent to World.MarshalYAML. Unlike writeJSONWorld, the whole document is built
+// before it is written.
+func writeYAMLWorld(out io.Writer, src worldSource) error {
+	resources, err := src.resources()
+	if err != nil {
+		return err
+	}
+	resourcesNode, err := componentsToYAML(resources)
+	if err != nil {
+		return err
+	}
+	entitiesNode := &yaml.Node{Kind: yaml.SequenceNode}
+	for i := 0; i < src.entityCount(); i++ {
+		e, err := src.entity(i)
+		if err != nil {
+			return err
+		}
+		entityNode, err := entityToYAML(e)
+		if err != nil {
+			return err
+		}
+		entitiesNode.Content = append(entitiesNode.Content, entityNode)
+	}
+
+	controllersNode := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, c := range src.controllers() {
+		controllersNode.Content = append(controllersNode.Content, &yaml.Node{
+			Kind: yaml.MappingNode,
+			Content: []*yaml.Node{
+				yamlString("name"), yamlString(c.Name),
+				yamlString("entity"), yamlString(c.Entity.String()),
+			},
+		})
+	}
+
+	doc := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			yamlString("turn"), {Kind: yaml.ScalarNode, Tag: "!!int", Value: fmt.Sprintf("%d", src.turn())},
+		},
+	}
+	if len(src.controllers()) > 0 {
+		doc.Content = append(doc.Content, yamlString("controllers"), controllersNode)
+	}
+	doc.Content = append(doc.Content,
+		yamlString("resources"), resourcesNode,
+		yamlString("entities"), entitiesNode,
+	)
+
+	return encodeYAML(out, doc)
+}
+
+// writeYAMLEntity writes the same document as yaml.Marshal produces for an Entity.
+func writeYAMLEntity(out io.Writer, e rawEntity) error {
+	node, err := entityToYAML(e)
+	if err != nil {
+		return err
+	}
+	return encodeYAML(out, node)
+}
+
+func encodeYAML(out io.Writer, node *yaml.Node) error {
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func entityToYAML(e rawEntity) (*yaml.Node, error) {
+	componentsNode, err := componentsToYAML(e.components)
+	if err != nil {
+		return nil, err
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	node.Content = append(node.Content, yamlString("uuid"), yamlString(e.id.String()))
+	if e.disabled {
+		node.Content = append(node.Content, yamlString("disabled"), &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+	}
+	node.Content = append(node.Content, yamlString("components"), componentsNode)
+	return node, nil
+}
+
+func yamlString(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func componentsToYAML(components []rawComponent) (*yaml.Node, error) {
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, c := range components {
+		var value *yaml.Node
+		if c.codec {
+			value = &yaml.Node{Kind: yaml.ScalarNode, Tag: codecTag, Value: base64.StdEncoding.EncodeToString(c.data)}
+		} else {
+			var err error
+			if value, err = jsonToYAML(c.data); err != nil {
+				return nil, fmt.Errorf("failed to convert %s to YAML: %w", c.name, err)
+			}
+		}
+		list.Content = append(list.Content, &yaml.Node{
+			Kind:    yaml.MappingNode,
+			Content: []*yaml.Node{yamlString(c.name), value},
+		})
+	}
+	return list, nil
+}
+
+// UnmarshalYAML reads a single component from a list written by ComponentStore.MarshalYAML.
+func (c *savedComponent) UnmarshalYAML(node *yaml.Node) error {
+	node = resolveYAMLAlias(node)
+	if node.Kind != yaml.MappingNode || len(node.Content) != 2 {
+		return fmt.Errorf("line %d: each component must be a map with a single key naming the component", node.Line)
+	}
+	name, value := node.Content[0].Value, resolveYAMLAlias(node.Content[1])
+	if value.Tag == codecTag {
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value.Value))
+		if err != nil {
+			return fmt.Errorf("line %d: invalid codec data for %s: %w", value.Line, name, err)
+		}
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		*c = savedComponent{Type: name, Encoding: encodingCodec, Data: encoded}
+		return nil
+	}
+	data, err := yamlToJSON(value)
+	if err != nil {
+		return fmt.Errorf("line %d: %s: %w", value.Line, name, err)
+	}
+	*c = savedComponent{Type: name, Data: data}
+	return nil
+}
+
+func resolveYAMLAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// jsonToYAML converts a JSON document to a YAML node, preserving the order of object keys.
+func jsonToYAML(data []byte) (*yaml.Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return jsonValueToYAML(dec)
+}
+
+func jsonValueToYAML(dec *json.Decoder) (*yaml.Node, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		kind, end := yaml.SequenceNode, json.Delim(']')
+		if t == '{' {
+			kind, end = yaml.MappingNode, json.Delim('}')
+		}
+		node := &yaml.Node{Kind: kind}
+		for dec.More() {
+			if kind == yaml.MappingNode {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, yamlString(key.(string)))
+			}
+			value, err := jsonValueToYAML(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		if closing, err := dec.Token(); err != nil {
+			return nil, err
+		} else if closing != end {
+			return nil, fmt.Errorf("unexpected %v", closing)
+		}
+		return node, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(t.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: t.String()}, nil
+	case string:
+		return yamlString(t), nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprintf("%t", t)}, nil
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+	return nil, fmt.Errorf("unexpected JSON token %v", token)
+}
+
+// yamlToJSON converts a YAML node to a JSON document, preserving the order of mapping keys.
+func yamlToJSON(node *yaml.Node) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := writeYAMLAsJSON(buf, node); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeYAMLAsJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	node = resolveYAMLAlias(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(resolveYAMLAlias(node.Content[i]).Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeYAMLAsJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeYAMLAsJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.ScalarNode:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	default:
+		return fmt.Errorf("line %d: unsupported YAML value", node.Line)
+	}
+	return nil
+}
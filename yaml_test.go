@@ -0,0 +1,121 @@
+package ecs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEntityYAMLIsKeyedByComponentName(t *testing.T) {
+	e := NewEntity()
+	e.Add(&TestComponent{X: 3})
+	e.Add(&NestedComponent{Name: "thing", Tags: []string{"a"}})
+
+	data, err := yaml.Marshal(e)
+	require.NoError(t, err)
+
+	assert.Equal(t, `uuid: `+e.ID().String()+`
+components:
+  - TestComponent:
+        X: 3
+  - NestedComponent:
+        name: thing
+        tags:
+          - a
+        ref: null
+`, string(data))
+}
+
+func TestHandWrittenYAMLEntityCanBeLoaded(t *testing.T) {
+	id := uuid.New()
+	data := `
+uuid: ` + id.String() + `
+components:
+  - TestComponent: &base
+      X: 7
+  - NestedComponent:
+      name: "goblin"
+      stats: {hp: 10, str: 3}
+      tags: [enemy, small]
+  - TestComponent: *base
+`
+	e, err := LoadEntity(strings.NewReader(data))
+	require.NoError(t, err)
+
+	assert.Equal(t, id, e.ID())
+	components := e.Store.List()
+	require.Len(t, components, 3)
+	assert.Equal(t, &TestComponent{X: 7}, components[0])
+	assert.Equal(t, &NestedComponent{
+		Name:  "goblin",
+		Stats: map[string]int{"hp": 10, "str": 3},
+		Tags:  []string{"enemy", "small"},
+	}, components[1])
+	assert.Equal(t, &TestComponent{X: 7}, components[2])
+}
+
+func TestCodecComponentsRoundTripThroughYAML(t *testing.T) {
+	e := buildCodecEntity()
+
+	data, err := yaml.Marshal(e)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "GridComponent: !codec AQIDBA==")
+
+	var loaded Entity
+	require.NoError(t, yaml.Unmarshal(data, &loaded))
+	assert.Equal(t, e, &loaded)
+}
+
+func TestWorldYAMLRoundTrip(t *testing.T) {
+	world := buildSaveWorld(3)
+
+	data, err := yaml.Marshal(world)
+	require.NoError(t, err)
+
+	loaded := NewWorld(0)
+	require.NoError(t, yaml.Unmarshal(data, loaded))
+
+	divergence, err := CompareWorlds(world, loaded)
+	require.NoError(t, err)
+	assert.Nil(t, divergence)
+	assert.Equal(t, world.Player().ID(), loaded.Player().ID())
+}
+
+func TestStreamedYAMLMatchesMarshalYAML(t *testing.T) {
+	world := buildSaveWorld(3)
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, world.Save(buf, SaveOptions{Format: FormatYAML}))
+
+	var streamed, marshalled interface{}
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &streamed))
+	data, err := yaml.Marshal(world)
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, &marshalled))
+	assert.Equal(t, marshalled, streamed)
+}
+
+func TestYAMLWithUnknownComponentFails(t *testing.T) {
+	_, err := LoadEntity(strings.NewReader(`
+uuid: ` + uuid.New().String() + `
+components:
+  - MissingComponent:
+      X: 1
+`))
+	assert.Error(t, err)
+}
+
+func TestYAMLComponentsMustBeSingleEntryMaps(t *testing.T) {
+	_, err := LoadEntity(strings.NewReader(`
+uuid: ` + uuid.New().String() + `
+components:
+  - TestComponent: {X: 1}
+    NestedComponent: {name: a}
+`))
+	assert.Error(t, err)
+}